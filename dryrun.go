@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+)
+
+// dryRunPrefix prefixes the explanations of the dry-run mode
+const dryRunPrefix = "[dry-run] "
+
+// explainf prints an explanation of the dry-run mode - on the standard error, so that the standard output is unchanged
+func explainf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, dryRunPrefix+format+"\n", args...)
+}
+
+// explainDecision explains how the next version of the scope was calculated: the previous tag, the commits and the bump
+func (sc scope) explainDecision(previousVersion semver.Version, nextVersion *semver.Version) {
+	d := sc.decision
+	if d.PreviousTag != "" {
+		explainf("Previous version%s: %s - from tag %s on commit %s", sc.description(), previousVersion.Original(), d.PreviousTag, shortHash(d.PreviousCommit))
+	} else {
+		explainf("Previous version%s: %s - using the %q strategy", sc.description(), previousVersion.Original(), options.previousVersion)
+	}
+
+	if d.Commits != nil || d.CommitsCount > 0 {
+		explainf("%d commits since the previous version%s:", d.CommitsCount, sc.description())
+		for _, c := range d.Commits {
+			explainf("  %s %s => %s", shortHash(c.Hash), c.Headline, classification(c))
+		}
+	}
+
+	if d.Bump != "" {
+		explainf("Bump: %s - %s", d.Bump, d.Reason)
+	}
+	if nextVersion == nil {
+		explainf("No release needed%s", sc.description())
+		return
+	}
+	explainf("Next version%s: %s", sc.description(), nextVersion.String())
+}
+
+// classification explains how a commit was classified
+func classification(c strategy.CommitDecision) string {
+	switch {
+	case c.Breaking:
+		return fmt.Sprintf("breaking change in a %q commit: %s", c.Type, c.Bump)
+	case c.Type != "":
+		return fmt.Sprintf("%q commit: %s", c.Type, c.Bump)
+	default:
+		return fmt.Sprintf("not a conventional commit: %s", c.Bump)
+	}
+}
+
+// explainRelease explains what would be released for the new version - without writing anything.
+// It returns the name of the tag it would create, and false: nothing is pushed.
+func explainRelease(ctx context.Context, sc scope, previousVersion, nextVersion semver.Version, output string) (string, bool) {
+	if options.updateFiles == "auto" {
+		explainf("Would write version %s in the version file detected in %s", nextVersion.String(), sc.fileDir)
+	} else if options.updateFiles != "" {
+		for _, filePath := range strings.Split(options.updateFiles, ",") {
+			explainf("Would write version %s in %s", nextVersion.String(), filepath.Join(sc.fileDir, strings.TrimSpace(filePath)))
+		}
+	}
+
+	if options.changelog != "" {
+		changelogPath := options.changelog
+		if !filepath.IsAbs(changelogPath) {
+			changelogPath = filepath.Join(sc.fileDir, changelogPath)
+		}
+		explainf("Would write the changelog of version %s in %s", output, changelogPath)
+	}
+
+	if !options.tag {
+		return "", false
+	}
+
+	tagOptions := newTag(ctx, sc, previousVersion, output)
+	head := "HEAD"
+	if repo, err := git.PlainOpen(options.dir); err == nil {
+		if ref, err := repo.Head(); err == nil {
+			head = shortHash(ref.Hash().String())
+		}
+	}
+	switch {
+	case tagOptions.Lightweight:
+		explainf("Would create the lightweight tag %s on commit %s", tagOptions.FormattedVersion, head)
+	default:
+		kind := "annotated"
+		if tagOptions.SignFormat != "" {
+			kind = fmt.Sprintf("%s-signed annotated", tagOptions.SignFormat)
+		}
+		message := tagOptions.Message
+		if message == "" {
+			message = fmt.Sprintf("Release version %s", tagOptions.FormattedVersion)
+		}
+		explainf("Would create the %s tag %s on commit %s with the message %q", kind, tagOptions.FormattedVersion, head, message)
+	}
+	if tagOptions.PushTag {
+		ref := "refs/tags/" + tagOptions.FormattedVersion
+		explainf("Would push %s:%s to the %s remote", ref, ref, tagOptions.GitAuth.RemoteName())
+	}
+	return tagOptions.FormattedVersion, false
+}
+
+// shortHash returns the abbreviated hash of a commit
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
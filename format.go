@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/Masterminds/sprig/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/branch"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// formatData is the data of the output format template.
+// It embeds the next version, so that its fields and methods - such as {{.Major}}.{{.Minor}}.{{.Patch}} - can be used directly.
+type formatData struct {
+	*semver.Version
+	// PreviousVersion is the previous version
+	PreviousVersion *semver.Version
+	// NextVersion is the next version - the same as the embedded version
+	NextVersion *semver.Version
+	// PreviousTag is the git tag of the previous version - if any
+	PreviousTag string
+	// SHA is the hash of the HEAD commit, and ShortSHA its abbreviated hash
+	SHA      string
+	ShortSHA string
+	// Branch is the current branch - empty if unknown
+	Branch string
+	// CommitDate is the committer date of the HEAD commit
+	CommitDate time.Time
+	// CommitsCount is the number of commits since the previous version - counted by the semantic strategy
+	CommitsCount int
+	// Bump is the bump level, such as major, minor, patch or none
+	Bump string
+	// Component is the name of the component of a monorepo - empty for the whole repository
+	Component string
+}
+
+// newFormatData returns the data of the output format template.
+// The git information is only set if the directory is a git repository.
+func newFormatData(sc scope, previousVersion, nextVersion semver.Version) formatData {
+	data := formatData{
+		Version:         &nextVersion,
+		PreviousVersion: &previousVersion,
+		NextVersion:     &nextVersion,
+	}
+	if d := sc.decision; d != nil {
+		data.PreviousTag, data.CommitsCount, data.Bump = d.PreviousTag, d.CommitsCount, d.Bump
+	}
+	if sc.component != nil {
+		data.Component = sc.component.Name
+	}
+
+	repo, err := git.PlainOpen(options.dir)
+	if err != nil {
+		log.Logger().Debugf("No git information for the output format: failed to open git repository at %q: %v", options.dir, err)
+		return data
+	}
+	head, err := repo.Head()
+	if err != nil {
+		log.Logger().Debugf("No git information for the output format: failed to get the HEAD reference: %v", err)
+		return data
+	}
+	data.SHA = head.Hash().String()
+	data.ShortSHA = shortHash(data.SHA)
+	if commit, err := repo.CommitObject(head.Hash()); err == nil {
+		data.CommitDate = commit.Committer.When
+	}
+	if name, err := branch.Current(options.dir); err == nil {
+		data.Branch = name
+	}
+	return data
+}
+
+// formatVersion formats the next version with the output format template
+func formatVersion(sc scope, previousVersion, nextVersion semver.Version) (string, error) {
+	outputTemplate, err := template.New("output").Funcs(sprig.TxtFuncMap()).Parse(options.outputFormat)
+	if err != nil {
+		return "", err
+	}
+
+	output := new(strings.Builder)
+	err = outputTemplate.Execute(output, newFormatData(sc, previousVersion, nextVersion))
+	if err != nil {
+		return "", err
+	}
+
+	return output.String(), nil
+}
@@ -1,7 +1,32 @@
 #!/bin/sh -le
 
-version=$(jx-release-version)
+# the env vars of the inputs - the env of action.yml
+inputs="
+  PREVIOUS_VERSION NEXT_VERSION OUTPUT_FORMAT PRERELEASE PROMOTE BRANCH_RULES
+  UPDATE_FILES CHANGELOG CHANGELOG_TEMPLATE TAG TAG_PREFIX PUSH_TAG GIT_TOKEN
+  GIT_REMOTE GIT_SSH_KEY GIT_SSH_KEY_PASSPHRASE GIT_NAME GIT_EMAIL TAG_MESSAGE
+  TAG_LIGHTWEIGHT SIGN_TAG SIGNING_KEY SIGNING_KEY_PASSPHRASE VERIFY_TAG_KEYS
+"
+
+# the inputs which aren't set are empty env vars: unset them - and only them -
+# so that the defaults of jx-release-version - and its configuration file - are used
+for name in $inputs; do
+  eval "value=\${$name-}"
+  if [ -z "$value" ]; then
+    unset "$name"
+  fi
+done
+
+# the exit code 3 means that no release is needed: it isn't a failure
+released=true
+version=$(jx-release-version) || status=$?
+case "${status:-0}" in
+  0) ;;
+  3) released=false ;;
+  *) exit "$status" ;;
+esac
 echo "version=$version" >> $GITHUB_OUTPUT
+echo "released=$released" >> $GITHUB_OUTPUT
 
 previous_version=$(jx-release-version --print-previous-version)
 echo "previous-version=$previous_version" >> $GITHUB_OUTPUT
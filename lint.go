@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/gitauth"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/lint"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/release"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/auto"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// lintCommand is the name of the command which checks the commit messages
+const lintCommand = "lint"
+
+// lintOptions are the flags of the lint command
+type lintOptions struct {
+	dir             string
+	since           string
+	commitHeadlines string
+	tagPrefix       string
+	types           string
+	scopes          string
+	firstParent     bool
+}
+
+// runLint checks the commits since the previous version - or since a revision - with the same parser as the semantic strategy,
+// prints a report for each commit, and fails if at least one commit doesn't follow the conventional commits specification or the rules.
+func runLint(ctx context.Context, args []string) {
+	var lo lintOptions
+	wd, _ := os.Getwd()
+	flags := flag.NewFlagSet(lintCommand, flag.ExitOnError)
+	flags.StringVar(&lo.dir, "dir", wd, "The directory that contains the git repository. Default to the current working directory.")
+	flags.StringVar(&lo.since, "since", getEnvWithDefault("LINT_SINCE", ""), "Check the commits since this revision - such as a branch, a tag or a commit hash - like `git log <revision>..HEAD`. Default to the LINT_SINCE env var, or the tag of the previous version.")
+	flags.StringVar(&lo.commitHeadlines, "commit-headlines", getEnvWithDefault("COMMIT_HEADLINES", ""), "The commit headline(s) to check instead of the commits of the repository. Default to the COMMIT_HEADLINES env var.")
+	flags.StringVar(&lo.tagPrefix, "tag-prefix", getEnvWithDefault("TAG_PREFIX", "v"), "The prefix of the tag of the previous version.")
+	flags.StringVar(&lo.types, "types", getEnvWithDefault("LINT_TYPES", ""), "The comma-separated allowed commit types, or * for any type. Default to the LINT_TYPES env var, or "+strings.Join(lint.DefaultTypes, ",")+".")
+	flags.StringVar(&lo.scopes, "scopes", getEnvWithDefault("LINT_SCOPES", ""), "The comma-separated allowed scopes. Default to the LINT_SCOPES env var, or any scope.")
+	flags.BoolVar(&lo.firstParent, "first-parent", os.Getenv("LINT_FIRST_PARENT") == "true", "Only check the first parent of the merge commits - like `git log --first-parent`.")
+	// the same options as the release to find the tag of the previous version
+	flags.BoolVar(&options.fetchTags, "fetch-tags", getEnvWithDefault("FETCH_TAGS", "") == "true", "Fetch tags from the remote origin before detecting the previous version")
+	flags.BoolVar(&options.remoteTags, "remote-tags", os.Getenv("REMOTE_TAGS") == "true", "Detect the previous version from the tags of the remote, listed without fetching them, and only fetch the tag of the previous version.")
+	flags.BoolVar(&options.reachableTags, "reachable-tags", os.Getenv("REACHABLE_TAGS") == "true", "Only detect the previous version from the tags reachable from HEAD - ignoring the tags of unmerged branches.")
+	flags.BoolVar(&options.nearestTag, "nearest-tag", os.Getenv("NEAREST_TAG") == "true", "Detect the previous version from the tag nearest to HEAD - like git describe - instead of the highest version. Implies -reachable-tags.")
+	flags.StringVar(&options.gitRemote, "git-remote", getEnvWithDefault("GIT_REMOTE", gitauth.DefaultRemote), "The name or the URL of the git remote used to fetch the tags. Default to the GIT_REMOTE env var.")
+	flags.BoolVar(&options.debug, "debug", os.Getenv("JX_LOG_LEVEL") == "debug", "Print debug logs. Enabled by default if the JX_LOG_LEVEL env var is set to 'debug'.")
+	_ = flags.Parse(args) // exits on error
+
+	if options.debug {
+		if err := os.Setenv("JX_LOG_LEVEL", "debug"); err != nil {
+			log.Logger().Warnf("failed to set JX_LOG_LEVEL: %v", err)
+		}
+	}
+
+	commits, err := lo.commits(ctx)
+	if err != nil {
+		log.Logger().Fatalf("Failed to list the commits to check: %v", err)
+	}
+
+	rules := lint.Rules{
+		Types:  splitList(lo.types),
+		Scopes: splitList(lo.scopes),
+	}
+	results := rules.Check(commits)
+	for _, r := range results {
+		commit := r.Headline
+		if r.Hash != "" {
+			commit = shortHash(r.Hash) + " " + commit
+		}
+		switch {
+		case r.Ignored:
+			fmt.Printf("skip  %s\n", commit)
+		case r.Problem != "":
+			fmt.Printf("FAIL  %s\n      %s\n", commit, r.Problem)
+		default:
+			fmt.Printf("ok    %s\n", commit)
+		}
+	}
+
+	if failed := lint.Failed(results); failed > 0 {
+		log.Logger().Fatalf("%d of the %d commits don't follow the conventional commits specification", failed, len(results))
+	}
+	log.Logger().Debugf("All the %d commits follow the conventional commits specification", len(results))
+}
+
+// commits returns the commits to check: the commit headlines, the commits since the revision,
+// or the commits since the tag of the previous version - all the commits if there is none
+func (lo lintOptions) commits(ctx context.Context) ([]semantic.Commit, error) {
+	so, err := release.Options{
+		Dir:             lo.dir,
+		TagPrefix:       lo.tagPrefix,
+		CommitHeadlines: lo.commitHeadlines,
+		FetchTags:       options.fetchTags,
+		RemoteTags:      options.remoteTags,
+		ReachableTags:   options.reachableTags,
+		NearestTag:      options.nearestTag,
+		GitAuth:         gitAuth(),
+	}.StrategyOptions()
+	if err != nil {
+		return nil, err
+	}
+	if lo.since != "" || lo.commitHeadlines != "" {
+		return so.Semantic(release.Semantic{FirstParent: lo.firstParent}).CommitsSince(ctx, lo.since)
+	}
+
+	// the same tag reader as the auto strategy of the release
+	previous, err := auto.Strategy{FromTagStrategy: so.FromTag("")}.ReadVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// created after reading the previous version, to use its tag
+	s := so.Semantic(release.Semantic{FirstParent: lo.firstParent})
+	commits, err := s.Commits(ctx, previous)
+	if errors.Is(err, semantic.ErrPreviousVersionTagNotFound) {
+		log.Logger().Debugf("The git repository has no tag for the previous version %s - checking all the commits", previous.String())
+		return s.Commits(ctx, nil)
+	}
+	return commits, err
+}
+
+// splitList splits a comma-separated list, ignoring the empty values
+func splitList(s string) []string {
+	var values []string
+	for _, value := range strings.Split(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
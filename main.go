@@ -1,25 +1,45 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
-	"text/template"
+	"syscall"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
-	"github.com/Masterminds/sprig/v3"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/branch"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/component"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/config"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/gitauth"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/release"
 	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
-	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/auto"
 	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/fromfile"
-	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/fromtag"
-	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/increment"
-	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/manual"
 	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
 	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/tag"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 )
 
+const (
+	// defaultOutputFormat only prints the major, minor and patch components of the next version
+	defaultOutputFormat = "{{.Major}}.{{.Minor}}.{{.Patch}}"
+	// prereleaseOutputFormat is the default output format for pre-releases, which also prints the pre-release
+	prereleaseOutputFormat = "{{.Major}}.{{.Minor}}.{{.Patch}}{{if .Prerelease}}-{{.Prerelease}}{{end}}"
+	// calverOutputFormat is the default output format for calendar versions, which keeps their zero-padded and missing segments
+	calverOutputFormat = "{{.Original}}"
+	// describeOutputFormat is the default output format for development versions, which also prints the pre-release and the metadata
+	describeOutputFormat = "{{.String}}"
+)
+
+// noReleaseExitCode is the exit code used when the commits since the previous version don't require a release
+const noReleaseExitCode = 3
+
 var (
 	// these are set at compile time through LD Flags
 	Version  = "dev"
@@ -36,22 +56,84 @@ var (
 		commitHeadlines      string
 		nextVersion          string
 		outputFormat         string
+		output               string
+		prerelease           string
+		promote              bool
 		tag                  bool
 		tagPrefix            string
 		pushTag              bool
 		fetchTags            bool
+		remoteTags           bool
+		reachableTags        bool
+		nearestTag           bool
 		gitName              string
 		gitEmail             string
+		gitRemote            string
+		sshKey               string
+		sshKnownHosts        string
+		sshInsecure          bool
+		updateFiles          string
+		bumpRules            string
+		components           string
+		branchRules          string
+		changelog            string
+		changelogTemplate    string
+		tagMessage           string
+		tagLightweight       bool
+		signTag              string
+		signingKey           string
+		verifyTag            string
+		dryRun               bool
+		timeout              time.Duration
 	}
 )
 
+// flagEnvVars are the env vars of the flags which can be set in the configuration file
+var flagEnvVars = map[string]string{
+	"previous-version":   "PREVIOUS_VERSION",
+	"next-version":       "NEXT_VERSION",
+	"output":             "JX_RELEASE_VERSION_OUTPUT",
+	"output-format":      "OUTPUT_FORMAT",
+	"tag-prefix":         "TAG_PREFIX",
+	"bump-rules":         "BUMP_RULES",
+	"branch-rules":       "BRANCH_RULES",
+	"components":         "COMPONENTS",
+	"prerelease":         "PRERELEASE",
+	"promote":            "PROMOTE",
+	"update-files":       "UPDATE_FILES",
+	"changelog":          "CHANGELOG",
+	"changelog-template": "CHANGELOG_TEMPLATE",
+	"fetch-tags":         "FETCH_TAGS",
+	"remote-tags":        "REMOTE_TAGS",
+	"reachable-tags":     "REACHABLE_TAGS",
+	"nearest-tag":        "NEAREST_TAG",
+	"tag":                "TAG",
+	"push-tag":           "PUSH_TAG",
+	"git-user":           "GIT_NAME",
+	"git-email":          "GIT_EMAIL",
+	"git-remote":         "GIT_REMOTE",
+	"ssh-key":            "GIT_SSH_KEY_FILE",
+	"ssh-known-hosts":    "GIT_SSH_KNOWN_HOSTS",
+	"tag-message":        "TAG_MESSAGE",
+	"tag-lightweight":    "TAG_LIGHTWEIGHT",
+	"sign-tag":           "SIGN_TAG",
+	"signing-key":        "SIGNING_KEY_FILE",
+	"verify-tag":         "VERIFY_TAG_KEYS",
+	"timeout":            "TIMEOUT",
+}
+
 func init() {
 	wd, _ := os.Getwd()
 	flag.StringVar(&options.dir, "dir", wd, "The directory that contains the git repository. Default to the current working directory.")
 	flag.StringVar(&options.previousVersion, "previous-version", getEnvWithDefault("PREVIOUS_VERSION", "auto"), "The strategy to detect the previous version: auto, from-tag, from-file or manual. Default to the PREVIOUS_VERSION env var.")
 	flag.StringVar(&options.commitHeadlines, "commit-headlines", getEnvWithDefault("COMMIT_HEADLINES", ""), "The commit headline(s) to use for semantic next version instead of the commit()s of a repository. Default to empty.")
-	flag.StringVar(&options.nextVersion, "next-version", getEnvWithDefault("NEXT_VERSION", "auto"), "The strategy to calculate the next version: auto, semantic, from-file, increment or manual. Default to the NEXT_VERSION env var.")
-	flag.StringVar(&options.outputFormat, "output-format", getEnvWithDefault("OUTPUT_FORMAT", "{{.Major}}.{{.Minor}}.{{.Patch}}"), "The output format of the next version. Default to the OUTPUT_FORMAT env var.")
+	flag.StringVar(&options.nextVersion, "next-version", getEnvWithDefault("NEXT_VERSION", "auto"), "The strategy to calculate the next version: auto, semantic, from-file, increment, calver, describe or manual. Default to the NEXT_VERSION env var.")
+	flag.StringVar(&options.bumpRules, "bump-rules", getEnvWithDefault("BUMP_RULES", ""), "The comma-separated type=bump rules used by the semantic strategy, such as perf=minor,docs=none. Default to the BUMP_RULES env var.")
+	flag.StringVar(&options.outputFormat, "output-format", getEnvWithDefault("OUTPUT_FORMAT", defaultOutputFormat), "The output format of the next version. Default to the OUTPUT_FORMAT env var.")
+	flag.StringVar(&options.output, "output", getEnvWithDefault("JX_RELEASE_VERSION_OUTPUT", textOutput), "The output: text, to only print the next version, or json, to print the whole decision. Default to the JX_RELEASE_VERSION_OUTPUT env var.")
+	flag.StringVar(&options.prerelease, "prerelease", getEnvWithDefault("PRERELEASE", ""), "The pre-release channel - such as alpha, beta or rc - to bump the version to the next pre-release of the next version. Default to the PRERELEASE env var.")
+	flag.BoolVar(&options.promote, "promote", os.Getenv("PROMOTE") == "true", "If the previous version is a pre-release, promote it to the final release instead of bumping the version again.")
+	flag.BoolVar(&options.dryRun, "dry-run", os.Getenv("DRY_RUN") == "true", "Explain how the next version is calculated, and what would be released, without fetching, writing or tagging anything.")
 	flag.BoolVar(&options.debug, "debug", os.Getenv("JX_LOG_LEVEL") == "debug", "Print debug logs. Enabled by default if the JX_LOG_LEVEL env var is set to 'debug'.")
 	flag.BoolVar(&options.printVersion, "version", false, "Just print the version and do nothing.")
 	flag.BoolVar(&options.printPreviousVersion, "print-previous-version", false, "Instead of printing the next version, print the previous (current) version detected by the previous-version strategy.")
@@ -59,11 +141,38 @@ func init() {
 	flag.StringVar(&options.tagPrefix, "tag-prefix", getEnvWithDefault("TAG_PREFIX", "v"), "Prefix to use for the git tag")
 	flag.BoolVar(&options.pushTag, "push-tag", getEnvWithDefault("PUSH_TAG", "true") == "true", "Use with tag flag, pushes a git tag to the remote branch")
 	flag.BoolVar(&options.fetchTags, "fetch-tags", getEnvWithDefault("FETCH_TAGS", "") == "true", "Fetch tags from the remote origin before detecting the previous version")
+	flag.BoolVar(&options.remoteTags, "remote-tags", os.Getenv("REMOTE_TAGS") == "true", "Detect the previous version from the tags of the remote, listed without fetching them - like git ls-remote --tags - and only fetch the tag of the previous version.")
+	flag.BoolVar(&options.reachableTags, "reachable-tags", os.Getenv("REACHABLE_TAGS") == "true", "Only detect the previous version from the tags reachable from HEAD - ignoring the tags of unmerged branches.")
+	flag.BoolVar(&options.nearestTag, "nearest-tag", os.Getenv("NEAREST_TAG") == "true", "Detect the previous version from the tag nearest to HEAD - like git describe - instead of the highest version. Implies -reachable-tags.")
 	flag.StringVar(&options.gitName, "git-user", getEnvWithDefault("GIT_NAME", ""), "Name is the personal name of the author and the committer of a commit, use to override Git config")
 	flag.StringVar(&options.gitEmail, "git-email", getEnvWithDefault("GIT_EMAIL", ""), "Email is the email of the author and the committer of a commit, use to override Git config")
+	flag.StringVar(&options.gitRemote, "git-remote", getEnvWithDefault("GIT_REMOTE", gitauth.DefaultRemote), "The name or the URL of the git remote used to fetch and push the tags. Default to the GIT_REMOTE env var.")
+	flag.StringVar(&options.sshKey, "ssh-key", getEnvWithDefault("GIT_SSH_KEY_FILE", ""), "The private key file used to authenticate to an SSH git remote. Default to the GIT_SSH_KEY_FILE env var, or the content of the GIT_SSH_KEY env var, or the ssh-agent.")
+	flag.StringVar(&options.sshKnownHosts, "ssh-known-hosts", getEnvWithDefault("GIT_SSH_KNOWN_HOSTS", ""), "The known_hosts file used to check the host key of an SSH git remote. Default to the GIT_SSH_KNOWN_HOSTS env var, or ~/.ssh/known_hosts.")
+	flag.DurationVar(&options.timeout, "timeout", getEnvDuration("TIMEOUT"), "The timeout of each network operation on the git remote - such as fetching or pushing the tags - like 30s or 2m. Default to the TIMEOUT env var, or no timeout.")
+	flag.BoolVar(&options.sshInsecure, "ssh-insecure-ignore-host-key", os.Getenv("GIT_SSH_INSECURE_IGNORE_HOST_KEY") == "true", "Don't check the host key of an SSH git remote.")
+	flag.StringVar(&options.tagMessage, "tag-message", getEnvWithDefault("TAG_MESSAGE", ""), "The Go template of the message of the git tag, with the .Tag, .Version, .PreviousVersion, .Commits, .Changelog and .ReleaseNotes fields. Default to the TAG_MESSAGE env var, or 'Release version {{ .Tag }}'.")
+	flag.BoolVar(&options.tagLightweight, "tag-lightweight", os.Getenv("TAG_LIGHTWEIGHT") == "true", "Create a lightweight git tag, instead of an annotated tag.")
+	flag.StringVar(&options.signTag, "sign-tag", getEnvWithDefault("SIGN_TAG", ""), "Sign the git tag with an OpenPGP key - gpg - or an SSH key - ssh. Default to the SIGN_TAG env var.")
+	flag.StringVar(&options.signingKey, "signing-key", getEnvWithDefault("SIGNING_KEY_FILE", ""), "The file of the private key used to sign the git tag. Default to the SIGNING_KEY_FILE env var, or the content of the SIGNING_KEY env var.")
+	flag.StringVar(&options.verifyTag, "verify-tag", getEnvWithDefault("VERIFY_TAG_KEYS", ""), "Verify the signature of the tag of the previous version with the trusted keys of this file: an armored OpenPGP public keyring, or an SSH allowed signers file. Default to the VERIFY_TAG_KEYS env var.")
+	flag.StringVar(&options.branchRules, "branch-rules", getEnvWithDefault("BRANCH_RULES", ""), "The comma-separated pattern=policy[:channel] rules applied to the current branch, where policy is release, maintenance or prerelease. Default to the BRANCH_RULES env var.")
+	flag.StringVar(&options.components, "components", getEnvWithDefault("COMPONENTS", ""), "The comma-separated components of a monorepo to version independently, formatted as name=path[:tagPrefix]. Default to the COMPONENTS env var.")
+	flag.StringVar(&options.changelog, "changelog", getEnvWithDefault("CHANGELOG", ""), "Write the changelog of the next version - generated from the conventional commits - to this file. Default to the CHANGELOG env var.")
+	flag.StringVar(&options.changelogTemplate, "changelog-template", getEnvWithDefault("CHANGELOG_TEMPLATE", ""), "The Go template file used to render the changelog. Default to the CHANGELOG_TEMPLATE env var, or a Markdown template.")
+	flag.StringVar(&options.updateFiles, "update-files", getEnvWithDefault("UPDATE_FILES", ""), "Write the next version in project files: auto, or a comma-separated list of files. Default to the UPDATE_FILES env var.")
 }
 
 func main() {
+	// cancel the git operations when the pipeline is canceled
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == lintCommand {
+		runLint(ctx, os.Args[2:])
+		return
+	}
+
 	flag.Parse()
 
 	if options.printVersion {
@@ -78,157 +187,497 @@ func main() {
 		log.Logger().Debugf("jx-release-version %s running in debug mode in %s", Version, options.dir)
 	}
 
-	previousVersion, err := versionReader().ReadVersion()
+	if err := applyConfig(); err != nil {
+		log.Logger().Fatalf("Failed to apply the configuration file: %v", err)
+	}
+
+	if options.output != textOutput && options.output != jsonOutput {
+		log.Logger().Fatalf("Invalid output %q: must be %s or %s", options.output, textOutput, jsonOutput)
+	}
+	line, err := applyBranchRules()
 	if err != nil {
-		log.Logger().Fatalf("Failed to read previous version using %q: %v", options.previousVersion, err)
+		log.Logger().Fatalf("Failed to apply the branch rules %q: %v", options.branchRules, err)
+	}
+
+	// the default output format depends on the strategy, unless the output format is set
+	_, calver := parseBumper(options.nextVersion).(release.CalVer)
+	switch {
+	case options.outputFormat != defaultOutputFormat:
+	case calver:
+		options.outputFormat = calverOutputFormat
+	case isDescribe():
+		options.outputFormat = describeOutputFormat
+	case options.prerelease != "":
+		options.outputFormat = prereleaseOutputFormat
+	}
+
+	bumpRules, err := semantic.ParseRules(options.bumpRules)
+	if err != nil {
+		log.Logger().Fatalf("Failed to parse bump rules %q: %v", options.bumpRules, err)
+	}
+
+	if options.dryRun && options.fetchTags {
+		explainf("Would fetch the tags from the %s remote - using the local tags instead", options.gitRemote)
+	}
+	if options.dryRun && options.remoteTags {
+		explainf("Would read the tags of the %s remote, and fetch the tag of the previous version - using the local tags instead", options.gitRemote)
+	}
+
+	var results []result
+	if options.components != "" {
+		components, err := component.Parse(options.components, options.tagPrefix)
+		if err != nil {
+			log.Logger().Fatalf("Failed to parse components %q: %v", options.components, err)
+		}
+		for _, c := range components {
+			results = append(results, versionScope(ctx, componentScope(c, line), bumpRules))
+		}
+	} else {
+		results = append(results, versionScope(ctx, rootScope(line), bumpRules))
+	}
+
+	if options.output == jsonOutput {
+		if err := printJSON(results, options.components != ""); err != nil {
+			log.Logger().Fatalf("Failed to print the JSON output: %v", err)
+		}
 	}
-	log.Logger().Debugf("Previous version: %s", previousVersion.String())
 
 	if options.printPreviousVersion {
-		fmt.Print(previousVersion.Original())
 		return
 	}
+	for _, r := range results {
+		if r.Release {
+			return
+		}
+	}
+	if options.components != "" {
+		log.Logger().Infof("No release needed for any of the %d components", len(results))
+	}
+	os.Exit(noReleaseExitCode)
+}
 
-	nextVersion, err := versionBumper().BumpVersion(*previousVersion)
+// applyConfig sets the flags from the configuration file of the repository - if any.
+// The precedence is: flag > env var > configuration file > default value.
+func applyConfig() error {
+	cfg, err := config.Load(options.dir)
 	if err != nil {
-		log.Logger().Fatalf("Failed to bump version using %q: %v", options.nextVersion, err)
+		return err
+	}
+	if cfg == nil {
+		return nil
+	}
+	log.Logger().Debugf("Using the configuration file %s", filepath.Join(options.dir, config.FileName))
+
+	explicitFlags := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		explicitFlags[f.Name] = true
+	})
+	for name, value := range cfg.Flags() {
+		if explicitFlags[name] {
+			continue
+		}
+		if os.Getenv(flagEnvVars[name]) != "" {
+			// an empty env var - such as an unset input of the GitHub Action - doesn't override the configuration file
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q for flag -%s: %w", value, name, err)
+		}
 	}
-	log.Logger().Debugf("Next version: %s", nextVersion.String())
+	return nil
+}
+
+// versionScope calculates the next version of the scope - the whole repository or a component of a monorepo -
+// prints it with the text output, and releases it.
+func versionScope(ctx context.Context, sc scope, bumpRules semantic.Rules) result {
+	r := newResult(sc)
 
-	output, err := formatVersion(*nextVersion)
+	computed, err := release.Compute(ctx, sc.releaseOptions(bumpRules))
 	if err != nil {
-		log.Logger().Fatalf("Failed to format version %q with %q: %v", *nextVersion, options.outputFormat, err)
+		log.Logger().Fatalf("Failed to calculate the version%s: %v", sc.description(), err)
 	}
+	sc.decision = computed.Decision
+	previousVersion := computed.PreviousVersion
+	r.PreviousVersion = previousVersion.Original()
+	r.setDecision(sc.decision)
 
-	fmt.Print(output)
+	if options.printPreviousVersion {
+		sc.printText(previousVersion.Original())
+		return r
+	}
 
-	if options.tag {
-		tagOptions := tag.Tag{
-			FormattedVersion: options.tagPrefix + output,
-			Dir:              options.dir,
-			PushTag:          options.pushTag,
-			GitName:          options.gitName,
-			GitEmail:         options.gitEmail,
-		}
-		err = tagOptions.TagRemote()
-		if err != nil {
-			log.Logger().Fatalf("Failed to tag using version %s: %v", output, err)
+	if options.dryRun {
+		sc.explainDecision(*previousVersion, computed.NextVersion)
+	}
+	if !computed.Release() {
+		if sc.component != nil {
+			log.Logger().Infof("Component %s unchanged since version %s", sc.component.Name, previousVersion.String())
+		} else {
+			log.Logger().Infof("No release needed since version %s", previousVersion.String())
 		}
+		return r
 	}
+	nextVersion := computed.NextVersion
+
+	output, err := formatVersion(sc, *previousVersion, *nextVersion)
+	if err != nil {
+		log.Logger().Fatalf("Failed to format version %q with %q: %v", *nextVersion, options.outputFormat, err)
+	}
+	r.NextVersion = output
+	r.Release = true
+
+	sc.printText(output)
+
+	r.Tag, r.TagPushed = releaseVersion(ctx, sc, *previousVersion, *nextVersion, output)
+	return r
 }
 
-func versionReader() strategy.VersionReader {
-	var (
-		versionReader             strategy.VersionReader
-		strategyName, strategyArg string
-	)
+// releaseVersion updates the files, writes the changelog and creates the tag for the new version, if enabled.
+// The project files get the semantic version, while the changelog and the tag use the formatted output.
+// It returns the name of the tag - if created - and whether it was pushed.
+// With the dry-run mode, it only explains what it would do.
+func releaseVersion(ctx context.Context, sc scope, previousVersion, nextVersion semver.Version, output string) (string, bool) {
+	if options.dryRun {
+		return explainRelease(ctx, sc, previousVersion, nextVersion, output)
+	}
 
-	parts := strings.SplitN(options.previousVersion, ":", 2)
-	strategyName = parts[0]
-	if len(parts) > 1 {
-		strategyArg = parts[1]
+	if options.updateFiles != "" {
+		err := updateFiles(sc, nextVersion.String())
+		if err != nil {
+			log.Logger().Fatalf("Failed to update files with version %s: %v", nextVersion.String(), err)
+		}
 	}
 
-	switch strategyName {
-	case "auto", "":
-		versionReader = auto.Strategy{
-			FromTagStrategy: fromtag.Strategy{
-				Dir:       options.dir,
-				FetchTags: options.fetchTags,
-			},
+	if options.changelog != "" {
+		err := writeChangelog(ctx, sc, previousVersion, output)
+		if err != nil {
+			log.Logger().Fatalf("Failed to write the changelog of version %s: %v", output, err)
 		}
-	case "from-tag":
-		versionReader = fromtag.Strategy{
-			Dir:        options.dir,
-			TagPattern: strategyArg,
-			FetchTags:  options.fetchTags,
+	}
+
+	if options.tag {
+		tagOptions := newTag(ctx, sc, previousVersion, output)
+		if options.signTag != "" {
+			key, err := signingKey()
+			if err != nil {
+				log.Logger().Fatalf("Failed to read the signing key: %v", err)
+			}
+			tagOptions.SigningKey = key
+			tagOptions.SigningKeyPassphrase = os.Getenv("SIGNING_KEY_PASSPHRASE")
 		}
-	case "from-file":
-		versionReader = fromfile.Strategy{
-			Dir:      options.dir,
-			FilePath: strategyArg,
+		err := tagOptions.TagRemote(ctx)
+		if errors.Is(err, tag.ErrRemoteTagConflict) {
+			log.Logger().Fatalf("Failed to push the tag of version %s - the version has already been released from another commit: %v", output, err)
 		}
-	case "manual":
-		versionReader = manual.Strategy{
-			Version: strategyArg,
+		if err != nil {
+			log.Logger().Fatalf("Failed to tag using version %s: %v", output, err)
 		}
-	default:
-		versionReader = manual.Strategy{
-			Version: options.previousVersion,
+		return tagOptions.FormattedVersion, tagOptions.PushTag
+	}
+	return "", false
+}
+
+// newTag returns the options of the tag of the new version - without the signing key
+func newTag(ctx context.Context, sc scope, previousVersion semver.Version, output string) tag.Tag {
+	tagOptions := tag.Tag{
+		FormattedVersion: sc.tagPrefix + output,
+		Dir:              options.dir,
+		PushTag:          options.pushTag,
+		GitName:          options.gitName,
+		GitEmail:         options.gitEmail,
+		SignFormat:       options.signTag,
+		Lightweight:      options.tagLightweight,
+		GitAuth:          gitAuth(),
+	}
+	if options.tagMessage != "" && !options.tagLightweight {
+		message, err := tagMessage(ctx, sc, previousVersion, output, tagOptions.FormattedVersion)
+		if err != nil {
+			log.Logger().Fatalf("Failed to render the message of tag %s: %v", tagOptions.FormattedVersion, err)
 		}
+		tagOptions.Message = message
 	}
+	return tagOptions
+}
 
-	log.Logger().Debugf("Using %q version reader (with %q)", strategyName, strategyArg)
-	return versionReader
+// gitAuth returns the git remote used to fetch and push the tags, and its authentication
+func gitAuth() gitauth.Config {
+	c := gitauth.FromEnv()
+	c.Remote = options.gitRemote
+	c.SSHKeyFile = options.sshKey
+	c.KnownHostsFile = options.sshKnownHosts
+	c.InsecureIgnoreHostKey = options.sshInsecure
+	c.Timeout = options.timeout
+	return c
 }
 
-func versionBumper() strategy.VersionBumper {
-	var (
-		versionBumper             strategy.VersionBumper
-		strategyName, strategyArg string
-	)
+// signingKey returns the private key used to sign the tag: from the -signing-key file, or the SIGNING_KEY env var
+func signingKey() ([]byte, error) {
+	if options.signingKey != "" {
+		return os.ReadFile(options.signingKey)
+	}
+	if key := os.Getenv("SIGNING_KEY"); key != "" {
+		return []byte(key), nil
+	}
+	return nil, errors.New("no signing key: set the -signing-key flag, or the SIGNING_KEY env var")
+}
 
-	parts := strings.SplitN(options.nextVersion, ":", 2)
-	strategyName = parts[0]
-	if len(parts) > 1 {
-		strategyArg = parts[1]
+// verifyPreviousTag checks the signature of the tag of the previous version.
+// It fails if the previous version was not read from a tag: the verification was requested, so it can't be skipped.
+func verifyPreviousTag(previousTag string) error {
+	if previousTag == "" {
+		return errors.New("the previous version was not read from a git tag, so its signature can't be verified")
+	}
+	trustedKeys, err := os.ReadFile(options.verifyTag)
+	if err != nil {
+		return fmt.Errorf("failed to read the trusted keys: %w", err)
 	}
+	if err = tag.Verify(options.dir, previousTag, trustedKeys); err != nil {
+		return err
+	}
+	log.Logger().Debugf("Verified the signature of tag %s", previousTag)
+	return nil
+}
 
-	switch strategyName {
-	case "auto", "":
-		versionBumper = auto.Strategy{
-			SemanticStrategy: semantic.Strategy{
-				Dir:                   options.dir,
-				StripPrerelease:       strings.Contains(strategyArg, "strip-prerelease"),
-				CommitHeadlinesString: options.commitHeadlines,
-				TagPrefix:             options.tagPrefix,
-			},
-		}
-	case "semantic":
-		versionBumper = semantic.Strategy{
-			Dir:                   options.dir,
-			StripPrerelease:       strings.Contains(strategyArg, "strip-prerelease"),
-			CommitHeadlinesString: options.commitHeadlines,
-			TagPrefix:             options.tagPrefix,
-		}
-	case "from-file":
-		versionBumper = fromfile.Strategy{
-			Dir:      options.dir,
-			FilePath: strategyArg,
+// scope is the part of the git repository to version:
+// either the whole repository, or a component of a monorepo.
+type scope struct {
+	// fileDir is the directory used by the file-based strategies
+	fileDir string
+	// path restricts the commits used by the semantic strategy, relative to the root of the repository
+	path string
+	// tagPrefix is the prefix of the tags of this scope
+	tagPrefix string
+	// component is only set for a component of a monorepo
+	component *component.Component
+	// line is only set on a maintenance branch
+	line *branch.Line
+	// decision is filled by the strategies
+	decision *strategy.Decision
+}
+
+// description returns the description of the scope for the logs: empty for the whole repository
+func (sc scope) description() string {
+	if sc.component == nil {
+		return ""
+	}
+	return " of component " + sc.component.Name
+}
+
+// printText prints the version of the scope with the text output: `name=version` lines for the components of a monorepo
+func (sc scope) printText(version string) {
+	switch {
+	case options.output != textOutput:
+	case sc.component != nil:
+		fmt.Printf("%s=%s\n", sc.component.Name, version)
+	default:
+		fmt.Print(version)
+	}
+}
+
+func rootScope(line *branch.Line) scope {
+	return scope{
+		fileDir:   options.dir,
+		tagPrefix: options.tagPrefix,
+		line:      line,
+		decision:  &strategy.Decision{},
+	}
+}
+
+func componentScope(c component.Component, line *branch.Line) scope {
+	return scope{
+		fileDir:   filepath.Join(options.dir, filepath.FromSlash(c.Path)),
+		path:      c.Path,
+		tagPrefix: c.TagPrefix,
+		component: &c,
+		line:      line,
+		decision:  &strategy.Decision{},
+	}
+}
+
+// applyBranchRules applies the policy of the rule matching the current branch - if any:
+// it sets the pre-release channel for the prerelease policy, and returns the release line for the maintenance policy.
+func applyBranchRules() (*branch.Line, error) {
+	rules, err := branch.ParseRules(options.branchRules)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	name, err := branch.Current(options.dir)
+	if err != nil {
+		return nil, err
+	}
+	rule, found := rules.Match(name)
+	if !found {
+		log.Logger().Debugf("No branch rule matching branch %q - using the release policy", name)
+		return nil, nil
+	}
+	log.Logger().Debugf("Using the %s policy of branch rule %q for branch %q", rule.Policy, rule.Pattern, name)
+
+	switch rule.Policy {
+	case branch.PolicyPrerelease:
+		// the development version of the describe strategy is already a pre-release
+		if options.prerelease == "" && !options.promote && !isDescribe() {
+			options.prerelease = rule.ChannelFor(name)
 		}
-	case "increment":
-		versionBumper = increment.Strategy{
-			ComponentToIncrement: strategyArg,
+	case branch.PolicyMaintenance:
+		line, err := branch.ParseLine(name)
+		if err != nil {
+			return nil, err
 		}
-	case "manual":
-		versionBumper = manual.Strategy{
-			Version: strategyArg,
+		return &line, nil
+	}
+	return nil, nil
+}
+
+// releaseOptions returns the options used to calculate the next version of the scope
+func (sc scope) releaseOptions(bumpRules semantic.Rules) release.Options {
+	o := release.Options{
+		Dir:             options.dir,
+		PreviousVersion: parseReader(options.previousVersion),
+		NextVersion:     parseBumper(options.nextVersion),
+		TagPrefix:       sc.tagPrefix,
+		Component:       sc.component,
+		Line:            sc.line,
+		Prerelease:      options.prerelease,
+		Promote:         options.promote,
+		CommitHeadlines: options.commitHeadlines,
+		BumpRules:       bumpRules,
+		FetchTags:       options.fetchTags && !options.dryRun,
+		RemoteTags:      options.remoteTags && !options.dryRun,
+		ReachableTags:   options.reachableTags,
+		NearestTag:      options.nearestTag,
+		GitAuth:         gitAuth(),
+		PreviousOnly:    options.printPreviousVersion,
+	}
+	if options.verifyTag != "" {
+		o.VerifyPreviousTag = verifyPreviousTag
+	}
+	return o
+}
+
+func updateFiles(sc scope, version string) error {
+	filePaths := strings.Split(options.updateFiles, ",")
+	if options.updateFiles == "auto" {
+		// an empty file path means auto-detection
+		filePaths = []string{""}
+	}
+
+	for _, filePath := range filePaths {
+		writer := fromfile.Strategy{
+			Dir:      sc.fileDir,
+			FilePath: strings.TrimSpace(filePath),
 		}
-	default:
-		versionBumper = manual.Strategy{
-			Version: options.previousVersion,
+		log.Logger().Debugf("Writing version %s using file %q", version, writer.FilePath)
+		if err := writer.WriteVersion(version); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	log.Logger().Debugf("Using %q version bumper (with %q)", strategyName, strategyArg)
-	return versionBumper
+// writeChangelog writes the changelog of the commits since the previous version,
+// in the changelog file relative to the directory of the scope.
+func writeChangelog(ctx context.Context, sc scope, previousVersion semver.Version, version string) error {
+	c, _, err := newChangelog(ctx, sc, previousVersion, version)
+	if err != nil {
+		return err
+	}
+
+	changelogPath := options.changelog
+	if !filepath.IsAbs(changelogPath) {
+		changelogPath = filepath.Join(sc.fileDir, changelogPath)
+	}
+	log.Logger().Debugf("Writing the changelog of version %s to %s", version, changelogPath)
+
+	output, err := renderChangelog(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(changelogPath, []byte(output), 0644) // #nosec G306 -- the changelog is meant to be shared
 }
 
-func formatVersion(version semver.Version) (string, error) {
-	outputTemplate, err := template.New("output").Funcs(sprig.TxtFuncMap()).Parse(options.outputFormat)
+// newChangelog returns the changelog of the commits since the previous version, and the commits
+func newChangelog(ctx context.Context, sc scope, previousVersion semver.Version, version string) (changelog.Changelog, []semantic.Commit, error) {
+	o, err := sc.releaseOptions(nil).StrategyOptions()
 	if err != nil {
-		return "", err
+		return changelog.Changelog{}, nil, err
+	}
+	// the commits of the previous version tag, with the options of the next version strategy - such as first-parent
+	o.Decision = sc.decision
+	s := o.Semantic(semanticOptions(parseBumper(options.nextVersion)))
+
+	commits, err := s.Commits(ctx, &previousVersion)
+	if errors.Is(err, semantic.ErrPreviousVersionTagNotFound) {
+		log.Logger().Debugf("The git repository has no tag for the previous version %s - the changelog will include all the commits", previousVersion.String())
+		commits, err = s.Commits(ctx, nil)
+	}
+	if err != nil {
+		return changelog.Changelog{}, nil, err
+	}
+	log.Logger().Debugf("Found %d commits since the previous version %s", len(commits), previousVersion.String())
+
+	repositoryURL, err := changelog.RepositoryURL(options.dir, options.gitRemote)
+	if err != nil {
+		return changelog.Changelog{}, nil, err
+	}
+
+	return changelog.New(version, previousVersion.Original(), repositoryURL, commits), commits, nil
+}
+
+// renderChangelog renders the changelog with the changelog template - if set - or the default template
+func renderChangelog(c changelog.Changelog) (string, error) {
+	var changelogTemplate string
+	if options.changelogTemplate != "" {
+		content, err := os.ReadFile(options.changelogTemplate)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the changelog template: %w", err)
+		}
+		changelogTemplate = string(content)
 	}
 
 	output := new(strings.Builder)
-	err = outputTemplate.Execute(output, version)
+	err := c.Render(output, changelogTemplate)
 	if err != nil {
 		return "", err
 	}
-
 	return output.String(), nil
 }
 
+// tagMessage renders the message of the annotated tag with the -tag-message template
+func tagMessage(ctx context.Context, sc scope, previousVersion semver.Version, version, tagName string) (string, error) {
+	c, commits, err := newChangelog(ctx, sc, previousVersion, version)
+	if err != nil {
+		return "", err
+	}
+	releaseNotes, err := renderChangelog(c)
+	if err != nil {
+		return "", err
+	}
+
+	return tag.RenderMessage(options.tagMessage, tag.MessageData{
+		Tag:             tagName,
+		Version:         version,
+		PreviousVersion: previousVersion.Original(),
+		Commits:         commits,
+		Changelog:       c,
+		ReleaseNotes:    releaseNotes,
+	})
+}
+
+// getEnvDuration returns the duration of the env var, such as 30s - or zero if it isn't set
+func getEnvDuration(key string) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Logger().Warnf("Ignoring the invalid duration %q of the %s env var: %v", value, key, err)
+		return 0
+	}
+	return d
+}
+
 func getEnvWithDefault(key, defaultVal string) string {
 	if val, found := os.LookupEnv(key); found {
 		return val
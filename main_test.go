@@ -0,0 +1,522 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/config"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/release"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// runEnvVar is the env var which runs the command instead of the tests, to test the whole command in a sub-process
+const runEnvVar = "JX_RELEASE_VERSION_TEST_RUN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runEnvVar) == "true" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// run runs the command with the args in a sub-process - with a clean env - and returns its stdout, stderr and exit code
+func run(t *testing.T, args ...string) (string, string, int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = []string{runEnvVar + "=true", "PATH=" + os.Getenv("PATH"), "HOME=" + t.TempDir()}
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), stderr.String(), exitErr.ExitCode()
+	}
+	require.NoError(t, err)
+	return stdout.String(), stderr.String(), 0
+}
+
+// testCommit is a commit of a test repository, which writes the content - or its message - to the file, and is tagged with the tag - if any
+type testCommit struct {
+	message string
+	file    string
+	content string
+	tag     string
+}
+
+// newTestRepository creates a git repository with the commits, and returns its directory and the hashes of the tagged commits by tag
+func newTestRepository(t *testing.T, commits ...testCommit) (string, map[string]string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	hashes := map[string]string{}
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	for _, c := range commits {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(c.file)), 0o700))
+		content := c.content
+		if content == "" {
+			content = c.message + "\n"
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, c.file), []byte(content), 0o600))
+		_, err = worktree.Add(c.file)
+		require.NoError(t, err)
+		hash, err := worktree.Commit(c.message, &git.CommitOptions{Author: signature, Committer: signature})
+		require.NoError(t, err)
+		if c.tag != "" {
+			_, err = repo.CreateTag(c.tag, hash, nil)
+			require.NoError(t, err)
+			hashes[c.tag] = hash.String()
+		}
+	}
+	return dir, hashes
+}
+
+func TestJSONOutput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		commits          []testCommit
+		args             []string
+		expectedExitCode int
+		// expected is the JSON output, where ${tag} is the hash of the commit of the tag
+		expected string
+	}{
+		{
+			name: "release",
+			commits: []testCommit{
+				{message: "feat: initial commit", file: "README.md", tag: "v1.2.0"},
+				{message: "feat(api): add an endpoint", file: "api.go"},
+				{message: "fix: fix a crash", file: "main.go"},
+				{message: "chore: update the dependencies", file: "go.mod"},
+			},
+			expected: `{
+				"previousVersion": "v1.2.0",
+				"nextVersion": "1.3.0",
+				"release": true,
+				"strategies": {
+					"previousVersion": "auto",
+					"nextVersion": "auto"
+				},
+				"previousTag": "v1.2.0",
+				"previousCommit": "${v1.2.0}",
+				"bump": "minor",
+				"reason": "Found at least 1 \"feat\" commit",
+				"commitsCount": 3,
+				"commitTypes": {"feat": 1, "fix": 1, "chore": 1},
+				"tagPushed": false
+			}`,
+		},
+		{
+			name: "pre-release",
+			commits: []testCommit{
+				{message: "feat: initial commit", file: "README.md", tag: "v1.2.0"},
+				{message: "fix: fix a crash", file: "main.go"},
+			},
+			args: []string{"-prerelease", "rc"},
+			expected: `{
+				"previousVersion": "v1.2.0",
+				"nextVersion": "1.2.1-rc.1",
+				"release": true,
+				"strategies": {
+					"previousVersion": "auto",
+					"nextVersion": "auto",
+					"prerelease": "rc"
+				},
+				"previousTag": "v1.2.0",
+				"previousCommit": "${v1.2.0}",
+				"bump": "prerelease",
+				"reason": "Found at least 1 \"fix\" commit - Next rc pre-release of 1.2.1",
+				"commitsCount": 1,
+				"commitTypes": {"fix": 1},
+				"tagPushed": false
+			}`,
+		},
+		{
+			name: "no release",
+			commits: []testCommit{
+				{message: "feat: initial commit", file: "README.md", tag: "v1.2.0"},
+				{message: "chore: update the dependencies", file: "go.mod"},
+			},
+			args:             []string{"-bump-rules", "chore=none"},
+			expectedExitCode: noReleaseExitCode,
+			expected: `{
+				"previousVersion": "v1.2.0",
+				"release": false,
+				"strategies": {
+					"previousVersion": "auto",
+					"nextVersion": "auto"
+				},
+				"previousTag": "v1.2.0",
+				"previousCommit": "${v1.2.0}",
+				"bump": "none",
+				"reason": "Found only commits of types [chore] which don't require a release",
+				"commitsCount": 1,
+				"commitTypes": {"chore": 1},
+				"tagPushed": false
+			}`,
+		},
+		{
+			name: "components",
+			commits: []testCommit{
+				{message: "feat(api): initial commit", file: "api/main.go", tag: "api/v1.0.0"},
+				{message: "feat(web): initial commit", file: "web/index.html", tag: "web/v2.0.0"},
+				{message: "fix(api): fix a crash", file: "api/main.go"},
+			},
+			args: []string{"-components", "api=api,web=web"},
+			expected: `[
+				{
+					"component": "api",
+					"previousVersion": "1.0.0",
+					"nextVersion": "1.0.1",
+					"release": true,
+					"strategies": {
+						"previousVersion": "auto",
+						"nextVersion": "auto"
+					},
+					"previousTag": "api/v1.0.0",
+					"previousCommit": "${api/v1.0.0}",
+					"bump": "patch",
+					"reason": "Found at least 1 \"fix\" commit",
+					"commitsCount": 1,
+					"commitTypes": {"fix": 1},
+					"tagPushed": false
+				},
+				{
+					"component": "web",
+					"previousVersion": "2.0.0",
+					"release": false,
+					"strategies": {
+						"previousVersion": "auto",
+						"nextVersion": "auto"
+					},
+					"previousTag": "web/v2.0.0",
+					"previousCommit": "${web/v2.0.0}",
+					"bump": "none",
+					"reason": "Found no commits touching web",
+					"commitsCount": 0,
+					"tagPushed": false
+				}
+			]`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir, hashes := newTestRepository(t, test.commits...)
+			stdout, stderr, exitCode := run(t, append([]string{"-dir", dir, "-output", "json"}, test.args...)...)
+			require.Equal(t, test.expectedExitCode, exitCode, stderr)
+
+			// decode both sides, so that a missing, an additional or a renamed field fails the test
+			var expected, actual interface{}
+			require.NoError(t, json.Unmarshal([]byte(os.Expand(test.expected, func(tag string) string { return hashes[tag] })), &expected))
+			require.NoError(t, json.Unmarshal([]byte(stdout), &actual), stdout)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	t.Parallel()
+
+	outputFormat := "{{.Major}}.{{.Minor}}.{{.Patch}} {{.NextVersion}} {{.PreviousVersion}} {{.PreviousTag}} {{.SHA}} {{.ShortSHA}} {{.Branch}} " +
+		`{{.CommitDate.UTC.Format "2006-01-02T15:04:05"}} {{.CommitsCount}} {{.Bump}} [{{.Component}}]`
+	tests := []struct {
+		name string
+		args []string
+		// expected is the output, where ${SHA} and ${ShortSHA} are the hashes of the HEAD commit
+		expected string
+	}{
+		{
+			name:     "whole repository",
+			expected: "1.3.0 1.3.0 1.2.0 v1.2.0 ${SHA} ${ShortSHA} master 2026-01-02T03:04:05 2 minor []",
+		},
+		{
+			name:     "component",
+			args:     []string{"-components", "api=api"},
+			expected: "api=1.0.1 1.0.1 1.0.0 api/v1.0.0 ${SHA} ${ShortSHA} master 2026-01-02T03:04:05 1 patch [api]\n",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir, _ := newTestRepository(t,
+				testCommit{message: "feat: initial commit", file: "README.md", tag: "v1.2.0"},
+				testCommit{message: "feat(api): initial commit", file: "api/main.go", tag: "api/v1.0.0"},
+				testCommit{message: "fix(api): fix a crash", file: "api/main.go"},
+			)
+			repo, err := git.PlainOpen(dir)
+			require.NoError(t, err)
+			head, err := repo.Head()
+			require.NoError(t, err)
+
+			stdout, stderr, exitCode := run(t, append([]string{"-dir", dir, "-output-format", outputFormat}, test.args...)...)
+			require.Equal(t, 0, exitCode, stderr)
+			hashes := map[string]string{"SHA": head.Hash().String(), "ShortSHA": head.Hash().String()[:7]}
+			expected := os.Expand(test.expected, func(name string) string { return hashes[name] })
+			assert.Equal(t, expected, stdout)
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		commits          []testCommit
+		args             []string
+		expected         string
+		expectedExitCode int
+	}{
+		{
+			name: "since the previous version",
+			commits: []testCommit{
+				{message: "an initial commit", file: "README.md", tag: "v1.2.0"},
+				{message: "feat: add an endpoint", file: "api.go"},
+			},
+			expected: "ok    ${HEAD} feat: add an endpoint\n",
+		},
+		{
+			name: "since the previous version with a tag prefix",
+			commits: []testCommit{
+				{message: "an initial commit", file: "README.md", tag: "release-1.2.0"},
+				{message: "feat: add an endpoint", file: "api.go"},
+			},
+			args:     []string{"-tag-prefix", "release-"},
+			expected: "ok    ${HEAD} feat: add an endpoint\n",
+		},
+		{
+			name: "without previous version",
+			commits: []testCommit{
+				{message: "an initial commit", file: "README.md", tag: "release-1.2.0"},
+				{message: "feat: add an endpoint", file: "api.go"},
+			},
+			expected:         "FAIL  ${ROOT} an initial commit\n      not a conventional commit: invalid character ' ' in type\nok    ${HEAD} feat: add an endpoint\n",
+			expectedExitCode: 1,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir, _ := newTestRepository(t, test.commits...)
+			repo, err := git.PlainOpen(dir)
+			require.NoError(t, err)
+			head, err := repo.Head()
+			require.NoError(t, err)
+			commit, err := repo.CommitObject(head.Hash())
+			require.NoError(t, err)
+			hashes := map[string]string{"HEAD": head.Hash().String()[:7], "ROOT": commit.ParentHashes[0].String()[:7]}
+
+			stdout, stderr, exitCode := run(t, append([]string{lintCommand, "-dir", dir}, test.args...)...)
+			require.Equal(t, test.expectedExitCode, exitCode, stderr)
+			expected := os.Expand(test.expected, func(name string) string { return hashes[name] })
+			assert.Equal(t, expected, stdout)
+		})
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	packageJSON := `{"name": "test", "version": "1.2.0"}` + "\n"
+	dir, hashes := newTestRepository(t,
+		testCommit{message: "feat: initial commit", file: "package.json", content: packageJSON, tag: "v1.2.0"},
+		testCommit{message: "fix: fix a crash", file: "main.go"},
+		testCommit{message: "docs: document the API", file: "README.md"},
+	)
+
+	stdout, stderr, exitCode := run(t, "-dir", dir, "-dry-run", "-tag", "-changelog", "CHANGELOG.md", "-update-files", "package.json", "-bump-rules", "docs=none")
+	require.Equal(t, 0, exitCode, stderr)
+
+	// only the next version is printed on stdout, and the explanations on stderr
+	assert.Equal(t, "1.2.1", stdout)
+	for _, line := range strings.Split(strings.TrimSpace(stderr), "\n") {
+		assert.True(t, strings.HasPrefix(line, dryRunPrefix), "unexpected line on stderr: %s", line)
+	}
+	for _, expected := range []string{
+		"Previous version: v1.2.0 - from tag v1.2.0 on commit " + hashes["v1.2.0"][:7],
+		"2 commits since the previous version:",
+		`fix: fix a crash => "fix" commit: patch`,
+		`docs: document the API => "docs" commit: none`,
+		`Bump: patch - Found at least 1 "fix" commit`,
+		"Next version: 1.2.1",
+		"Would write version 1.2.1 in " + filepath.Join(dir, "package.json"),
+		"Would write the changelog of version 1.2.1 in " + filepath.Join(dir, "CHANGELOG.md"),
+		`Would create the annotated tag v1.2.1 on commit`,
+		"Would push refs/tags/v1.2.1:refs/tags/v1.2.1 to the origin remote",
+	} {
+		assert.Contains(t, stderr, expected)
+	}
+
+	// nothing is written nor tagged
+	content, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	require.NoError(t, err)
+	assert.Equal(t, packageJSON, string(content))
+	assert.NoFileExists(t, filepath.Join(dir, "CHANGELOG.md"))
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	tags, err := repo.Tags()
+	require.NoError(t, err)
+	var tagNames []string
+	require.NoError(t, tags.ForEach(func(ref *plumbing.Reference) error {
+		tagNames = append(tagNames, ref.Name().Short())
+		return nil
+	}))
+	assert.Equal(t, []string{"v1.2.0"}, tagNames)
+}
+
+// actionInputRegexp matches the input of an env var of the GitHub Action, such as `${{ inputs.tag-prefix }}`
+var actionInputRegexp = regexp.MustCompile(`^\$\{\{\s*inputs\.([\w-]+)\s*}}$`)
+
+func TestApplyConfigWithActionEnv(t *testing.T) {
+	content, err := os.ReadFile("action.yml")
+	require.NoError(t, err)
+	var action struct {
+		Inputs map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"inputs"`
+		Runs struct {
+			Env map[string]string `yaml:"env"`
+		} `yaml:"runs"`
+	}
+	require.NoError(t, yaml.Unmarshal(content, &action))
+	require.NotEmpty(t, action.Runs.Env)
+
+	// the env of the GitHub Action, with the default value of each input
+	for envVar, value := range action.Runs.Env {
+		matched := actionInputRegexp.FindStringSubmatch(value)
+		require.NotNil(t, matched, "env var %s is not an input", envVar)
+		input, found := action.Inputs[matched[1]]
+		require.True(t, found, "unknown input %s of env var %s", matched[1], envVar)
+		assert.Empty(t, input.Default, "the default of input %s overrides the configuration file", matched[1])
+		t.Setenv(envVar, input.Default)
+	}
+
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, config.FileName), []byte(`previousVersion: from-tag
+nextVersion: increment:minor
+outputFormat: "{{.Major}}.{{.Minor}}"
+tagPrefix: release-
+promote: true
+remote:
+  name: upstream
+tag:
+  enabled: true
+  push: false
+  lightweight: true
+`), 0o600)
+	require.NoError(t, err)
+
+	saved := options
+	t.Cleanup(func() { options = saved })
+	options.dir = dir
+
+	require.NoError(t, applyConfig())
+	assert.Equal(t, "from-tag", options.previousVersion)
+	assert.Equal(t, "increment:minor", options.nextVersion)
+	assert.Equal(t, "{{.Major}}.{{.Minor}}", options.outputFormat)
+	assert.Equal(t, "release-", options.tagPrefix)
+	assert.True(t, options.promote)
+	assert.Equal(t, "upstream", options.gitRemote)
+	assert.True(t, options.tag)
+	assert.False(t, options.pushTag)
+	assert.True(t, options.tagLightweight)
+}
+
+// entrypointInputsRegexp matches the env vars of the inputs listed in the entrypoint of the GitHub Action
+var entrypointInputsRegexp = regexp.MustCompile(`(?m)^inputs="([^"]*)"`)
+
+func TestEntrypointInputs(t *testing.T) {
+	t.Parallel()
+
+	content, err := os.ReadFile("action.yml")
+	require.NoError(t, err)
+	var action struct {
+		Runs struct {
+			Env map[string]string `yaml:"env"`
+		} `yaml:"runs"`
+	}
+	require.NoError(t, yaml.Unmarshal(content, &action))
+	var expected []string
+	for envVar := range action.Runs.Env {
+		expected = append(expected, envVar)
+	}
+
+	// only the env vars of the inputs are unset when empty
+	content, err = os.ReadFile(filepath.Join("hack", "github-actions-entrypoint.sh"))
+	require.NoError(t, err)
+	matched := entrypointInputsRegexp.FindSubmatch(content)
+	require.NotNil(t, matched)
+	assert.ElementsMatch(t, expected, strings.Fields(string(matched[1])))
+}
+
+func TestParseStrategies(t *testing.T) {
+	t.Parallel()
+
+	readers := []struct {
+		flag     string
+		expected release.Reader
+	}{
+		{flag: "", expected: release.Auto{}},
+		{flag: "auto", expected: release.Auto{}},
+		{flag: "from-tag", expected: release.FromTag{}},
+		{flag: "from-tag:^v1", expected: release.FromTag{Pattern: "^v1"}},
+		{flag: "from-file:package.json", expected: release.FromFile{Path: "package.json"}},
+		{flag: "manual:1.2.3", expected: release.Manual{Version: "1.2.3"}},
+		{flag: "1.2.3", expected: release.Manual{Version: "1.2.3"}},
+		{flag: "semantic", expected: release.Manual{Version: "semantic"}},
+	}
+	for _, test := range readers {
+		assert.Equal(t, test.expected, parseReader(test.flag), "previous version %q", test.flag)
+	}
+
+	bumpers := []struct {
+		flag     string
+		expected release.Bumper
+	}{
+		{flag: "", expected: release.Auto{}},
+		{flag: "auto:first-parent", expected: release.Auto{Semantic: release.Semantic{FirstParent: true}}},
+		{flag: "semantic", expected: release.Semantic{}},
+		{flag: "semantic:strip-prerelease,first-parent", expected: release.Semantic{StripPrerelease: true, FirstParent: true}},
+		{flag: "from-file:Chart.yaml", expected: release.FromFile{Path: "Chart.yaml"}},
+		{flag: "increment:minor", expected: release.Increment{Component: "minor"}},
+		{flag: "calver", expected: release.CalVer{}},
+		{flag: "calver:YYYY.MM.DD", expected: release.CalVer{Format: "YYYY.MM.DD"}},
+		{flag: "describe", expected: release.Describe{}},
+		{flag: "describe:alpha,first-parent", expected: release.Describe{Channel: "alpha", Semantic: release.Semantic{FirstParent: true}}},
+		{flag: "manual:1.2.3", expected: release.Manual{Version: "1.2.3"}},
+		{flag: "1.2.3", expected: release.Manual{Version: "1.2.3"}},
+	}
+	for _, test := range bumpers {
+		assert.Equal(t, test.expected, parseBumper(test.flag), "next version %q", test.flag)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+)
+
+const (
+	// textOutput only prints the next - or previous - version
+	textOutput = "text"
+	// jsonOutput prints the whole decision as JSON
+	jsonOutput = "json"
+)
+
+// result is the decision for a scope, printed with the JSON output.
+// The JSON field names are a stable contract for the pipelines and dashboards: only add new fields.
+type result struct {
+	// Component is empty for the whole repository
+	Component       string     `json:"component,omitempty"`
+	PreviousVersion string     `json:"previousVersion"`
+	NextVersion     string     `json:"nextVersion,omitempty"`
+	Release         bool       `json:"release"`
+	Strategies      strategies `json:"strategies"`
+	PreviousTag     string     `json:"previousTag,omitempty"`
+	PreviousCommit  string     `json:"previousCommit,omitempty"`
+	Bump            string     `json:"bump,omitempty"`
+	Reason          string     `json:"reason,omitempty"`
+	CommitsCount    int        `json:"commitsCount"`
+	// CommitTypes is the number of conventional commits by type
+	CommitTypes map[string]int `json:"commitTypes,omitempty"`
+	Tag         string         `json:"tag,omitempty"`
+	TagPushed   bool           `json:"tagPushed"`
+	// DryRun is true if nothing was actually released: the tag was neither created nor pushed
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// strategies are the strategies used to calculate the versions
+type strategies struct {
+	PreviousVersion string `json:"previousVersion"`
+	NextVersion     string `json:"nextVersion"`
+	Prerelease      string `json:"prerelease,omitempty"`
+	Promote         bool   `json:"promote,omitempty"`
+	ReleaseLine     string `json:"releaseLine,omitempty"`
+}
+
+func newResult(sc scope) result {
+	r := result{
+		Strategies: strategies{
+			PreviousVersion: options.previousVersion,
+			NextVersion:     options.nextVersion,
+			Prerelease:      options.prerelease,
+			Promote:         options.promote,
+		},
+		DryRun: options.dryRun,
+	}
+	if sc.component != nil {
+		r.Component = sc.component.Name
+	}
+	if sc.line != nil {
+		r.Strategies.ReleaseLine = sc.line.String()
+	}
+	return r
+}
+
+func (r *result) setDecision(d *strategy.Decision) {
+	if d == nil {
+		return
+	}
+	r.PreviousTag = d.PreviousTag
+	r.PreviousCommit = d.PreviousCommit
+	r.Bump = d.Bump
+	r.Reason = d.Reason
+	r.CommitsCount = d.CommitsCount
+	r.CommitTypes = d.CommitTypes
+}
+
+// printJSON prints the result of the whole repository as a JSON object,
+// or the results of the components of a monorepo as a JSON array
+func printJSON(results []result, components bool) error {
+	var v interface{} = results
+	if !components {
+		v = results[0]
+	}
+	output, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+	return nil
+}
@@ -0,0 +1,190 @@
+package branch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+var (
+	ErrUnknownBranch = errors.New("could not find the current branch: HEAD is detached and no CI environment variable is set")
+)
+
+var (
+	// channelInvalidCharsRegexp matches the characters which can't be used in a pre-release
+	channelInvalidCharsRegexp = regexp.MustCompile(`[^0-9A-Za-z-]+`)
+)
+
+// ciBranchEnvVars are the environment variables set by the CI systems with the name of the branch being built,
+// for when HEAD is detached. The branch of a pull request comes first.
+var ciBranchEnvVars = []string{
+	"GITHUB_HEAD_REF",                     // GitHub Actions - pull requests
+	"GITHUB_REF_NAME",                     // GitHub Actions - only for the branches, see GITHUB_REF_TYPE
+	"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", // GitLab CI - merge requests
+	"CI_COMMIT_BRANCH",                    // GitLab CI
+	"PULL_HEAD_REF",                       // Lighthouse / Prow - pull requests
+	"PULL_BASE_REF",                       // Lighthouse / Prow
+	"CHANGE_BRANCH",                       // Jenkins - pull requests
+	"BRANCH_NAME",                         // Jenkins / Jenkins X
+	"GIT_BRANCH",                          // Jenkins git plugin
+	"BUILDKITE_BRANCH",                    // Buildkite
+	"CIRCLE_BRANCH",                       // CircleCI
+	"TRAVIS_PULL_REQUEST_BRANCH",          // Travis CI - pull requests
+	"TRAVIS_BRANCH",                       // Travis CI
+	"BITBUCKET_BRANCH",                    // Bitbucket Pipelines
+	"DRONE_SOURCE_BRANCH",                 // Drone
+	"SYSTEM_PULLREQUEST_SOURCEBRANCH",     // Azure Pipelines - pull requests
+	"BUILD_SOURCEBRANCH",                  // Azure Pipelines
+}
+
+// Current returns the name of the current branch: from HEAD, or from the CI environment variables if HEAD is detached.
+func Current(dir string) (string, error) {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to open git repository at %q: %w", dir, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the HEAD reference for git repository at %q: %w", dir, err)
+	}
+	if head.Name().IsBranch() {
+		return head.Name().Short(), nil
+	}
+
+	for _, envVar := range ciBranchEnvVars {
+		if envVar == "GITHUB_REF_NAME" && os.Getenv("GITHUB_REF_TYPE") != "branch" {
+			// the name of a tag, or the name of another ref
+			continue
+		}
+		if name := normalize(os.Getenv(envVar)); name != "" {
+			log.Logger().Debugf("HEAD is detached - using the branch %q from the %s env var", name, envVar)
+			return name, nil
+		}
+	}
+	return "", ErrUnknownBranch
+}
+
+// normalize removes the refs and remote prefixes from a branch name, such as `refs/heads/` or `origin/`
+func normalize(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "refs/") && !strings.HasPrefix(name, "refs/heads/") {
+		// such as a tag: it's not a branch
+		return ""
+	}
+	name = strings.TrimPrefix(name, "refs/heads/")
+	return strings.TrimPrefix(name, "origin/")
+}
+
+// Policy is the way to version the commits of a branch
+type Policy string
+
+const (
+	// PolicyRelease produces normal releases
+	PolicyRelease Policy = "release"
+	// PolicyMaintenance only produces releases in the release line of the branch, such as `1.2` for `release/1.2`
+	PolicyMaintenance Policy = "maintenance"
+	// PolicyPrerelease produces pre-releases, in the channel named after the branch by default
+	PolicyPrerelease Policy = "prerelease"
+)
+
+// Rule is the policy of the branches matching a glob pattern
+type Rule struct {
+	// Pattern is a glob pattern, such as `main` or `release/*`: `*` matches any characters except `/`, `**` matches any characters
+	Pattern string
+	Policy  Policy
+	regexp  *regexp.Regexp
+	// Channel is the pre-release channel of the prerelease policy. If empty, it is derived from the name of the branch.
+	Channel string
+}
+
+// Rules are the branch rules, in order: the first rule matching the branch is used
+type Rules []Rule
+
+// ParseRules parses a comma-separated list of `pattern=policy[:channel]` rules, such as `main=release,release/*=maintenance,*=prerelease`
+func ParseRules(s string) (Rules, error) {
+	var rules Rules
+	for _, rawRule := range strings.Split(s, ",") {
+		if strings.TrimSpace(rawRule) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(rawRule, "=", 2)
+		pattern := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || pattern == "" {
+			return nil, fmt.Errorf("invalid branch rule %q: must be formatted as pattern=policy[:channel]", rawRule)
+		}
+		rule := Rule{Pattern: pattern, regexp: globToRegexp(pattern)}
+		policy, channel, _ := strings.Cut(strings.TrimSpace(parts[1]), ":")
+		rule.Policy = Policy(strings.ToLower(strings.TrimSpace(policy)))
+		rule.Channel = strings.TrimSpace(channel)
+		switch rule.Policy {
+		case PolicyRelease, PolicyMaintenance, PolicyPrerelease:
+		default:
+			return nil, fmt.Errorf("invalid branch rule %q: the policy must be one of release, maintenance or prerelease", rawRule)
+		}
+		if rule.Channel != "" && rule.Policy != PolicyPrerelease {
+			return nil, fmt.Errorf("invalid branch rule %q: only the prerelease policy has a channel", rawRule)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Match returns the first rule matching the given branch
+func (r Rules) Match(branch string) (Rule, bool) {
+	for _, rule := range r {
+		if rule.regexp == nil {
+			rule.regexp = globToRegexp(rule.Pattern)
+		}
+		if rule.regexp.MatchString(branch) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// globToRegexp converts a glob pattern to a regular expression
+func globToRegexp(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+// ChannelFor returns the pre-release channel of the rule for the given branch:
+// either the channel of the rule, or the name of the branch with only the characters allowed in a pre-release,
+// such as `feature-foo` for `feature/foo`.
+func (r Rule) ChannelFor(branch string) string {
+	if r.Channel != "" {
+		return r.Channel
+	}
+	channel := strings.Trim(channelInvalidCharsRegexp.ReplaceAllString(branch, "-"), "-")
+	if channel == "" {
+		return "branch"
+	}
+	if _, err := strconv.Atoi(channel); err == nil {
+		// a pre-release identifier can't be numeric
+		return "branch-" + channel
+	}
+	return channel
+}
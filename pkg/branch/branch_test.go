@@ -0,0 +1,153 @@
+package branch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := ParseRules("main=release, release/*=maintenance,preview/**=prerelease:preview,**=prerelease")
+	require.NoError(t, err)
+
+	tests := []struct {
+		branch          string
+		expectedPattern string
+		expectedPolicy  Policy
+		expectedChannel string
+	}{
+		{branch: "main", expectedPattern: "main", expectedPolicy: PolicyRelease, expectedChannel: "main"},
+		{branch: "release/1.x", expectedPattern: "release/*", expectedPolicy: PolicyMaintenance, expectedChannel: "release-1-x"},
+		{branch: "release/1.2/hotfix", expectedPattern: "**", expectedPolicy: PolicyPrerelease, expectedChannel: "release-1-2-hotfix"},
+		{branch: "preview/ui/dark-mode", expectedPattern: "preview/**", expectedPolicy: PolicyPrerelease, expectedChannel: "preview"},
+		{branch: "feature/foo", expectedPattern: "**", expectedPolicy: PolicyPrerelease, expectedChannel: "feature-foo"},
+		{branch: "1234", expectedPattern: "**", expectedPolicy: PolicyPrerelease, expectedChannel: "branch-1234"},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.branch, func(t *testing.T) {
+			t.Parallel()
+
+			rule, found := rules.Match(test.branch)
+			require.True(t, found)
+			assert.Equal(t, test.expectedPattern, rule.Pattern)
+			assert.Equal(t, test.expectedPolicy, rule.Policy)
+			assert.Equal(t, test.expectedChannel, rule.ChannelFor(test.branch))
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		rules            string
+		expected         int
+		expectedErrorMsg string
+	}{
+		{
+			name:  "empty",
+			rules: "",
+		},
+		{
+			name:     "valid rules",
+			rules:    "main=release,feature/*=PreRelease:alpha,",
+			expected: 2,
+		},
+		{
+			name:             "missing policy",
+			rules:            "main",
+			expectedErrorMsg: `invalid branch rule "main": must be formatted as pattern=policy[:channel]`,
+		},
+		{
+			name:             "unknown policy",
+			rules:            "main=publish",
+			expectedErrorMsg: `invalid branch rule "main=publish": the policy must be one of release, maintenance or prerelease`,
+		},
+		{
+			name:             "channel without prerelease",
+			rules:            "main=release:rc",
+			expectedErrorMsg: `invalid branch rule "main=release:rc": only the prerelease policy has a channel`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ParseRules(test.rules)
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	hash, err := w.Commit("chore: init", &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+	require.NoError(t, err)
+	require.NoError(t, w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature/foo"), Create: true}))
+
+	for _, envVar := range ciBranchEnvVars {
+		t.Setenv(envVar, "")
+	}
+	t.Setenv("GITHUB_REF_TYPE", "")
+
+	actual, err := Current(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "feature/foo", actual)
+
+	require.NoError(t, w.Checkout(&git.CheckoutOptions{Hash: hash}))
+	_, err = Current(dir)
+	require.ErrorIs(t, err, ErrUnknownBranch)
+
+	t.Setenv("GIT_BRANCH", "origin/release/1.x")
+	actual, err = Current(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "release/1.x", actual)
+
+	// a Lighthouse pull request: the branch of the pull request, not the base branch
+	t.Setenv("PULL_BASE_REF", "main")
+	t.Setenv("PULL_PULL_REF", "refs/pull/12/head")
+	t.Setenv("PULL_HEAD_REF", "feature/baz")
+	actual, err = Current(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "feature/baz", actual)
+
+	// a GitHub Actions tag build, then branch build
+	t.Setenv("GITHUB_REF_TYPE", "tag")
+	t.Setenv("GITHUB_REF_NAME", "v1.0.0")
+	actual, err = Current(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "feature/baz", actual)
+	t.Setenv("GITHUB_REF_TYPE", "branch")
+	t.Setenv("GITHUB_REF_NAME", "main")
+	actual, err = Current(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "main", actual)
+
+	t.Setenv("BUILD_SOURCEBRANCH", "refs/tags/v1.0.0")
+	t.Setenv("GITHUB_HEAD_REF", "refs/heads/feature/bar")
+	actual, err = Current(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "feature/bar", actual)
+}
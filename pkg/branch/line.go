@@ -0,0 +1,73 @@
+package branch
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+var (
+	// lineRegexp matches the release line of a maintenance branch, such as `1.2` in `release/1.2` or `1.x` in `release-1.x`
+	lineRegexp = regexp.MustCompile(`(?:^|[^0-9.])v?(\d+)\.(\d+|x)(?:$|[^0-9.])`)
+)
+
+// Line is the release line of a maintenance branch: either a minor line such as `1.2`, or a major line such as `1.x`
+type Line struct {
+	Major uint64
+	Minor uint64
+	// AnyMinor is true for a major line, such as `1.x`
+	AnyMinor bool
+}
+
+// ParseLine returns the release line from the name of a maintenance branch, such as `release/1.2` or `release-1.x`
+func ParseLine(branch string) (Line, error) {
+	matched := lineRegexp.FindStringSubmatch(branch)
+	if matched == nil {
+		return Line{}, fmt.Errorf("could not find a release line such as 1.2 or 1.x in the name of the maintenance branch %q", branch)
+	}
+
+	var (
+		line Line
+		err  error
+	)
+	line.Major, err = strconv.ParseUint(matched[1], 10, 64)
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid major version in the name of the maintenance branch %q: %w", branch, err)
+	}
+	if matched[2] == "x" {
+		line.AnyMinor = true
+		return line, nil
+	}
+	line.Minor, err = strconv.ParseUint(matched[2], 10, 64)
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid minor version in the name of the maintenance branch %q: %w", branch, err)
+	}
+	return line, nil
+}
+
+func (l Line) String() string {
+	if l.AnyMinor {
+		return fmt.Sprintf("%d.x", l.Major)
+	}
+	return fmt.Sprintf("%d.%d", l.Major, l.Minor)
+}
+
+// TagPattern returns a regular expression matching the versions of the line, to match after the tag prefix
+func (l Line) TagPattern() string {
+	if l.AnyMinor {
+		return fmt.Sprintf(`%d\.\d+\.\d+`, l.Major)
+	}
+	return fmt.Sprintf(`%d\.%d\.\d+`, l.Major, l.Minor)
+}
+
+// Contains returns true if the version belongs to the line
+func (l Line) Contains(v semver.Version) bool {
+	return v.Major() == l.Major && (l.AnyMinor || v.Minor() == l.Minor)
+}
+
+// First returns the first version of the line, such as `1.2.0` or `1.0.0`
+func (l Line) First() semver.Version {
+	return *semver.New(l.Major, l.Minor, 0, "", "")
+}
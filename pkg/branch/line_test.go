@@ -0,0 +1,69 @@
+package branch
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		branch           string
+		expected         Line
+		expectedIn       []string
+		expectedOut      []string
+		expectedErrorMsg string
+	}{
+		{
+			branch:      "release/1.2",
+			expected:    Line{Major: 1, Minor: 2},
+			expectedIn:  []string{"1.2.0", "1.2.13", "1.2.3-rc.1"},
+			expectedOut: []string{"1.3.0", "2.2.0", "0.1.2"},
+		},
+		{
+			branch:      "release-v2.x",
+			expected:    Line{Major: 2, AnyMinor: true},
+			expectedIn:  []string{"2.0.0", "2.13.4"},
+			expectedOut: []string{"1.9.9", "3.0.0"},
+		},
+		{
+			branch:      "maintenance/10.20-lts",
+			expected:    Line{Major: 10, Minor: 20},
+			expectedIn:  []string{"10.20.1"},
+			expectedOut: []string{"10.2.0"},
+		},
+		{
+			branch:           "release/1.2.3",
+			expectedErrorMsg: `could not find a release line such as 1.2 or 1.x in the name of the maintenance branch "release/1.2.3"`,
+		},
+		{
+			branch:           "main",
+			expectedErrorMsg: `could not find a release line such as 1.2 or 1.x in the name of the maintenance branch "main"`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.branch, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ParseLine(test.branch)
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+			for _, v := range test.expectedIn {
+				assert.True(t, actual.Contains(*semver.MustParse(v)), "%s should be in line %s", v, actual)
+			}
+			for _, v := range test.expectedOut {
+				assert.False(t, actual.Contains(*semver.MustParse(v)), "%s should not be in line %s", v, actual)
+			}
+		})
+	}
+}
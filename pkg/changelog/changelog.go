@@ -0,0 +1,298 @@
+package changelog
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/gitauth"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
+)
+
+var (
+	// issueRegexp matches the issue references, such as `#12` or `owner/repo#12`
+	issueRegexp = regexp.MustCompile(`(?:^|[^\w/&.:#-])((?:[\w.-]+/[\w.-]+)?#(\d+))\b`)
+	// scpLikeURLRegexp matches the scp-like git URLs, such as `git@github.com:owner/repo.git`
+	scpLikeURLRegexp = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):([^/].*)$`)
+)
+
+// typeTitles are the titles of the well-known conventional commit types, in the order of the changelog.
+// The other types are listed after, in alphabetical order, using the type as the title.
+var typeTitles = []struct {
+	Type  string
+	Title string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"revert", "Reverts"},
+	{"refactor", "Code Refactoring"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build System"},
+	{"ci", "Continuous Integration"},
+	{"style", "Styles"},
+	{"chore", "Chores"},
+}
+
+// DefaultTemplate renders the changelog as Markdown
+const DefaultTemplate = `## Changes in version {{ .Version }}
+{{- define "commit" }}{{ if .Hash }} ({{ if .URL }}[{{ .ShortHash }}]({{ .URL }}){{ else }}{{ .ShortHash }}{{ end }}){{ end }}
+{{- range $i, $issue := .BodyIssues }}{{ if $i }},{{ end }} {{ if .URL }}[{{ $issue }}]({{ .URL }}){{ else }}{{ $issue }}{{ end }}{{ end }}
+{{- end }}
+{{- if .BreakingChanges }}
+
+### BREAKING CHANGES
+{{ range .BreakingChanges }}
+- {{ if .Scope }}**{{ .Scope }}:** {{ end }}{{ .Breaking }}{{ template "commit" . }}
+{{- end }}
+{{- end }}
+{{- range .Groups }}
+
+### {{ .Title }}
+{{ range .Entries }}
+- {{ if .Scope }}**{{ .Scope }}:** {{ end }}{{ .Description }}{{ template "commit" . }}
+{{- end }}
+{{- end }}
+`
+
+// Changelog lists the conventional commits of a version, grouped by type
+type Changelog struct {
+	Version         string
+	PreviousVersion string
+	Date            time.Time
+	// RepositoryURL is the web URL of the repository, used to link the commits and issues. It might be empty.
+	RepositoryURL string
+	// Groups are the commits grouped by type - and sorted by scope in each group
+	Groups []Group
+	// BreakingChanges are the commits with a breaking change, whatever their type
+	BreakingChanges []Entry
+}
+
+// Group is the list of the commits of a conventional commit type
+type Group struct {
+	Type    string
+	Title   string
+	Entries []Entry
+}
+
+// Entry is a conventional commit
+type Entry struct {
+	Hash        string
+	ShortHash   string
+	URL         string
+	Author      string
+	Date        time.Time
+	Type        string
+	Scope       string
+	Description string
+	Body        string
+	// Breaking is the description of the breaking change - if any
+	Breaking string
+	// Issues are the issues referenced in the description, body or footers of the commit
+	Issues []Issue
+	// BodyIssues are the Issues which are not referenced in the description: the default template lists them after the commit,
+	// as the description already shows the others
+	BodyIssues []Issue
+}
+
+// Issue is a reference to an issue - or pull request - such as `#12` or `owner/repo#12`
+type Issue struct {
+	// Repository is empty for the issues of the same repository
+	Repository string
+	Number     string
+	URL        string
+}
+
+func (i Issue) String() string {
+	return i.Repository + "#" + i.Number
+}
+
+// New returns the changelog of the given commits. The commits which don't follow the conventional commits specification are ignored.
+func New(version, previousVersion, repositoryURL string, commits []semantic.Commit) Changelog {
+	changelog := Changelog{
+		Version:         version,
+		PreviousVersion: previousVersion,
+		Date:            time.Now(),
+		RepositoryURL:   strings.TrimSuffix(repositoryURL, "/"),
+	}
+
+	entriesByType := map[string][]Entry{}
+	for _, commit := range commits {
+		if commit.Conventional == nil {
+			continue
+		}
+		entry := changelog.newEntry(commit)
+		entriesByType[entry.Type] = append(entriesByType[entry.Type], entry)
+		if entry.Breaking != "" {
+			changelog.BreakingChanges = append(changelog.BreakingChanges, entry)
+		}
+	}
+
+	for _, typeTitle := range typeTitles {
+		if entries, found := entriesByType[typeTitle.Type]; found {
+			changelog.Groups = append(changelog.Groups, newGroup(typeTitle.Type, typeTitle.Title, entries))
+			delete(entriesByType, typeTitle.Type)
+		}
+	}
+	otherTypes := make([]string, 0, len(entriesByType))
+	for commitType := range entriesByType {
+		otherTypes = append(otherTypes, commitType)
+	}
+	sort.Strings(otherTypes)
+	for _, commitType := range otherTypes {
+		changelog.Groups = append(changelog.Groups, newGroup(commitType, commitType, entriesByType[commitType]))
+	}
+
+	return changelog
+}
+
+func newGroup(commitType, title string, entries []Entry) Group {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Scope < entries[j].Scope
+	})
+	return Group{
+		Type:    commitType,
+		Title:   title,
+		Entries: entries,
+	}
+}
+
+func (c Changelog) newEntry(commit semantic.Commit) Entry {
+	entry := Entry{
+		Hash:        commit.Hash,
+		ShortHash:   commit.Hash,
+		Author:      commit.Author,
+		Date:        commit.Date,
+		Type:        strings.ToLower(commit.Conventional.Header.Type),
+		Scope:       commit.Conventional.Header.Scope,
+		Description: commit.Conventional.Header.Description,
+		Body:        commit.Conventional.Body,
+		Breaking:    commit.Conventional.BreakingMessage(),
+	}
+	if len(entry.ShortHash) > 7 {
+		entry.ShortHash = entry.ShortHash[:7]
+	}
+	if c.RepositoryURL != "" && entry.Hash != "" {
+		entry.URL = c.RepositoryURL + "/commit/" + entry.Hash
+	}
+
+	texts := []string{entry.Description, entry.Body}
+	for _, footer := range commit.Conventional.Footer {
+		texts = append(texts, footer.Value)
+	}
+	seen := map[string]bool{}
+	for i, text := range texts {
+		for _, matched := range issueRegexp.FindAllStringSubmatch(text, -1) {
+			if seen[matched[1]] {
+				continue
+			}
+			seen[matched[1]] = true
+			issue := c.newIssue(matched[1], matched[2])
+			entry.Issues = append(entry.Issues, issue)
+			if i > 0 {
+				entry.BodyIssues = append(entry.BodyIssues, issue)
+			}
+		}
+	}
+
+	return entry
+}
+
+func (c Changelog) newIssue(reference, number string) Issue {
+	issue := Issue{
+		Repository: strings.TrimSuffix(reference, "#"+number),
+		Number:     number,
+	}
+	if c.RepositoryURL == "" {
+		return issue
+	}
+	if issue.Repository == "" {
+		issue.URL = c.RepositoryURL + "/issues/" + number
+		return issue
+	}
+	if u, err := url.Parse(c.RepositoryURL); err == nil {
+		issue.URL = fmt.Sprintf("%s://%s/%s/issues/%s", u.Scheme, u.Host, issue.Repository, number)
+	}
+	return issue
+}
+
+// Render writes the changelog using the given Go template - or the DefaultTemplate if empty.
+// The sprig functions are available in the template.
+func (c Changelog) Render(w io.Writer, tmpl string) error {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+
+	changelogTemplate, err := template.New("changelog").Funcs(sprig.TxtFuncMap()).Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to parse the changelog template: %w", err)
+	}
+
+	err = changelogTemplate.Execute(w, c)
+	if err != nil {
+		return fmt.Errorf("failed to render the changelog: %w", err)
+	}
+	return nil
+}
+
+// RepositoryURL returns the web URL of the repository, based on the URL of the given remote - or the given remote URL.
+// It returns an empty string if the remote URL can't be converted to a web URL - for a local path for example.
+func RepositoryURL(dir, remoteName string) (string, error) {
+	if gitauth.IsURL(remoteName) {
+		return webURL(remoteName), nil
+	}
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to open git repository at %q: %w", dir, err)
+	}
+
+	remote, err := repo.Remote(remoteName)
+	if err == git.ErrRemoteNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get the remote %q: %w", remoteName, err)
+	}
+
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", nil
+	}
+	return webURL(urls[0]), nil
+}
+
+// webURL converts a git remote URL - such as `git@github.com:owner/repo.git` - to a web URL - such as `https://github.com/owner/repo`.
+// The credentials are always removed.
+func webURL(remoteURL string) string {
+	remoteURL = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(remoteURL), "/"), ".git")
+
+	if !strings.Contains(remoteURL, "://") {
+		matched := scpLikeURLRegexp.FindStringSubmatch(remoteURL)
+		if matched == nil {
+			return ""
+		}
+		return "https://" + matched[1] + "/" + matched[2]
+	}
+
+	u, err := url.Parse(remoteURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return u.Scheme + "://" + u.Host + u.Path
+	case "ssh", "git", "git+ssh", "ssh+git":
+		return "https://" + u.Hostname() + u.Path
+	default:
+		return ""
+	}
+}
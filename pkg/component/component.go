@@ -0,0 +1,72 @@
+package component
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	componentNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+// Component is a part of a monorepo which is versioned independently:
+// its previous version is read from its own tags, and only the commits
+// touching files in its directory are used to calculate its next version.
+type Component struct {
+	// Name identifies the component
+	Name string
+	// Path is the directory of the component, relative to the root of the git repository
+	Path string
+	// TagPrefix is the prefix of the component's tags, such as `api/v` for `api/v1.4.0`
+	TagPrefix string
+}
+
+// Parse parses a comma-separated list of components, formatted as `name=path[:tagPrefix]`,
+// such as `api=services/api:api/v,web=web`.
+// If a component has no tag prefix, it defaults to the name of the component,
+// followed by a slash and the given default tag prefix: `web/v` for example.
+func Parse(s, defaultTagPrefix string) ([]Component, error) {
+	var (
+		components []Component
+		names      = map[string]bool{}
+	)
+	for _, definition := range strings.Split(s, ",") {
+		definition = strings.TrimSpace(definition)
+		if definition == "" {
+			continue
+		}
+
+		parts := strings.SplitN(definition, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid component %q: must be formatted as name=path[:tagPrefix]", definition)
+		}
+		c := Component{
+			Name: strings.TrimSpace(parts[0]),
+			Path: strings.TrimSpace(parts[1]),
+		}
+		if i := strings.Index(c.Path, ":"); i >= 0 {
+			c.Path, c.TagPrefix = strings.TrimSpace(c.Path[:i]), strings.TrimSpace(c.Path[i+1:])
+		}
+		if !componentNameRegexp.MatchString(c.Name) {
+			return nil, fmt.Errorf("invalid component %q: invalid name %q", definition, c.Name)
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("invalid component %q: duplicate name %q", definition, c.Name)
+		}
+		names[c.Name] = true
+
+		c.Path = path.Clean(strings.ReplaceAll(c.Path, "\\", "/"))
+		if c.Path == "." || c.Path == ".." || strings.HasPrefix(c.Path, "../") || path.IsAbs(c.Path) {
+			return nil, fmt.Errorf("invalid component %q: the path must be a sub-directory of the repository", definition)
+		}
+		if c.TagPrefix == "" {
+			c.TagPrefix = c.Name + "/" + defaultTagPrefix
+		}
+
+		components = append(components, c)
+	}
+
+	return components, nil
+}
@@ -0,0 +1,76 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		components       string
+		expected         []Component
+		expectedErrorMsg string
+	}{
+		{
+			name:       "empty",
+			components: "",
+			expected:   nil,
+		},
+		{
+			name:       "default tag prefix",
+			components: "api=services/api, web=./web/",
+			expected: []Component{
+				{Name: "api", Path: "services/api", TagPrefix: "api/v"},
+				{Name: "web", Path: "web", TagPrefix: "web/v"},
+			},
+		},
+		{
+			name:       "custom tag prefix",
+			components: "my-chart=charts/my-chart:my-chart-",
+			expected: []Component{
+				{Name: "my-chart", Path: "charts/my-chart", TagPrefix: "my-chart-"},
+			},
+		},
+		{
+			name:             "missing path",
+			components:       "api",
+			expectedErrorMsg: "invalid component \"api\": must be formatted as name=path[:tagPrefix]",
+		},
+		{
+			name:             "duplicate name",
+			components:       "api=api,api=services/api",
+			expectedErrorMsg: "invalid component \"api=services/api\": duplicate name \"api\"",
+		},
+		{
+			name:             "path outside of the repository",
+			components:       "api=../api",
+			expectedErrorMsg: "invalid component \"api=../api\": the path must be a sub-directory of the repository",
+		},
+		{
+			name:             "root path",
+			components:       "api=.",
+			expectedErrorMsg: "invalid component \"api=.\": the path must be a sub-directory of the repository",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := Parse(test.components, "v")
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
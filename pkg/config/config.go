@@ -0,0 +1,287 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/branch"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/component"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/tag"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the configuration file, in the directory of the git repository
+const FileName = ".jx-release-version.yaml"
+
+// Config is the versioning policy of a repository. All the settings are optional.
+type Config struct {
+	PreviousVersion string `yaml:"previousVersion"`
+	NextVersion     string `yaml:"nextVersion"`
+	Output          string `yaml:"output"`
+	OutputFormat    string `yaml:"outputFormat"`
+	// TagPrefix is a pointer, because an empty tag prefix is valid
+	TagPrefix *string `yaml:"tagPrefix"`
+	// BumpRules maps the conventional commit types to the component to bump
+	BumpRules   map[string]string `yaml:"bumpRules"`
+	BranchRules []BranchRule      `yaml:"branchRules"`
+	Components  []Component       `yaml:"components"`
+	Prerelease  string            `yaml:"prerelease"`
+	Promote     *bool             `yaml:"promote"`
+	// UpdateFiles is either `auto`, or a list of files
+	UpdateFiles   StringList `yaml:"updateFiles"`
+	Changelog     Changelog  `yaml:"changelog"`
+	FetchTags     *bool      `yaml:"fetchTags"`
+	RemoteTags    *bool      `yaml:"remoteTags"`
+	ReachableTags *bool      `yaml:"reachableTags"`
+	NearestTag    *bool      `yaml:"nearestTag"`
+	Remote        Remote     `yaml:"remote"`
+	Tag           Tag        `yaml:"tag"`
+}
+
+// Remote is the git remote used to fetch and push the tags
+type Remote struct {
+	// Name is the name or the URL of the remote
+	Name       string `yaml:"name"`
+	SSHKey     string `yaml:"sshKey"`
+	KnownHosts string `yaml:"knownHosts"`
+	// Timeout is the timeout of each network operation on the remote, such as `30s`
+	Timeout string `yaml:"timeout"`
+}
+
+// BranchRule is the policy of the branches matching a glob pattern
+type BranchRule struct {
+	Branch  string `yaml:"branch"`
+	Policy  string `yaml:"policy"`
+	Channel string `yaml:"channel"`
+}
+
+// Component is a component of a monorepo
+type Component struct {
+	Name      string `yaml:"name"`
+	Path      string `yaml:"path"`
+	TagPrefix string `yaml:"tagPrefix"`
+}
+
+type Changelog struct {
+	File     string `yaml:"file"`
+	Template string `yaml:"template"`
+}
+
+type Tag struct {
+	Enabled  *bool  `yaml:"enabled"`
+	Push     *bool  `yaml:"push"`
+	GitUser  string `yaml:"gitUser"`
+	GitEmail string `yaml:"gitEmail"`
+	// Message is the Go template of the message of the annotated tag
+	Message     string `yaml:"message"`
+	Lightweight *bool  `yaml:"lightweight"`
+	// Sign is the format of the tag signature: gpg or ssh
+	Sign       string `yaml:"sign"`
+	SigningKey string `yaml:"signingKey"`
+	// VerifyKeys is the file of the trusted keys used to verify the tag of the previous version
+	VerifyKeys string `yaml:"verifyKeys"`
+}
+
+// StringList is a list of strings, which can also be written as a single string
+type StringList []string
+
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = StringList{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Load reads the configuration file from the given directory.
+// It returns nil if there is no configuration file.
+func Load(dir string) (*Config, error) {
+	filePath := filepath.Join(dir, FileName)
+	content, err := os.ReadFile(filePath) // #nosec G304 -- configuration file of the user-provided directory
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&cfg)
+	if errors.Is(err, io.EOF) {
+		// an empty file
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", filePath, err)
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", filePath, err)
+	}
+	return &cfg, nil
+}
+
+// Validate returns an error pointing to the first invalid key - if any
+func (c Config) Validate() error {
+	if c.Output != "" && c.Output != "text" && c.Output != "json" {
+		return keyError("output", fmt.Errorf("must be text or json, not %q", c.Output))
+	}
+
+	for _, commitType := range c.sortedBumpRuleTypes() {
+		if strings.ContainsAny(commitType, "=,") {
+			return keyError("bumpRules."+commitType, fmt.Errorf("invalid commit type %q", commitType))
+		}
+		if _, err := semantic.ParseBump(c.BumpRules[commitType]); err != nil {
+			return keyError("bumpRules."+commitType, err)
+		}
+	}
+
+	for i, rule := range c.BranchRules {
+		if strings.Contains(rule.String(), ",") {
+			return keyError(fmt.Sprintf("branchRules[%d]", i), errors.New("commas are not allowed"))
+		}
+		if _, err := branch.ParseRules(rule.String()); err != nil {
+			return keyError(fmt.Sprintf("branchRules[%d]", i), err)
+		}
+	}
+
+	for i, comp := range c.Components {
+		if strings.Contains(comp.String(), ",") {
+			return keyError(fmt.Sprintf("components[%d]", i), errors.New("commas are not allowed"))
+		}
+		if _, err := component.Parse(comp.String(), ""); err != nil {
+			return keyError(fmt.Sprintf("components[%d]", i), err)
+		}
+	}
+
+	if c.Tag.Sign != "" && c.Tag.Sign != tag.SignFormatGPG && c.Tag.Sign != tag.SignFormatSSH {
+		return keyError("tag.sign", fmt.Errorf("must be %s or %s, not %q", tag.SignFormatGPG, tag.SignFormatSSH, c.Tag.Sign))
+	}
+
+	if c.Remote.Timeout != "" {
+		if _, err := time.ParseDuration(c.Remote.Timeout); err != nil {
+			return keyError("remote.timeout", err)
+		}
+	}
+
+	for i, file := range c.UpdateFiles {
+		if strings.TrimSpace(file) == "" || strings.Contains(file, ",") {
+			return keyError(fmt.Sprintf("updateFiles[%d]", i), fmt.Errorf("invalid file %q", file))
+		}
+	}
+
+	return nil
+}
+
+// Flags returns the values of the settings of the configuration file, by CLI flag name
+func (c Config) Flags() map[string]string {
+	flags := map[string]string{}
+	setString := func(name, value string) {
+		if value != "" {
+			flags[name] = value
+		}
+	}
+	setBool := func(name string, value *bool) {
+		if value != nil {
+			flags[name] = strconv.FormatBool(*value)
+		}
+	}
+
+	setString("previous-version", c.PreviousVersion)
+	setString("next-version", c.NextVersion)
+	setString("output", c.Output)
+	setString("output-format", c.OutputFormat)
+	if c.TagPrefix != nil {
+		flags["tag-prefix"] = *c.TagPrefix
+	}
+
+	bumpRules := make([]string, 0, len(c.BumpRules))
+	for _, commitType := range c.sortedBumpRuleTypes() {
+		bumpRules = append(bumpRules, commitType+"="+c.BumpRules[commitType])
+	}
+	setString("bump-rules", strings.Join(bumpRules, ","))
+
+	branchRules := make([]string, 0, len(c.BranchRules))
+	for _, rule := range c.BranchRules {
+		branchRules = append(branchRules, rule.String())
+	}
+	setString("branch-rules", strings.Join(branchRules, ","))
+
+	components := make([]string, 0, len(c.Components))
+	for _, comp := range c.Components {
+		components = append(components, comp.String())
+	}
+	setString("components", strings.Join(components, ","))
+
+	setString("prerelease", c.Prerelease)
+	setBool("promote", c.Promote)
+	setString("update-files", strings.Join(c.UpdateFiles, ","))
+	setString("changelog", c.Changelog.File)
+	setString("changelog-template", c.Changelog.Template)
+	setBool("fetch-tags", c.FetchTags)
+	setBool("remote-tags", c.RemoteTags)
+	setBool("reachable-tags", c.ReachableTags)
+	setBool("nearest-tag", c.NearestTag)
+	setString("git-remote", c.Remote.Name)
+	setString("ssh-key", c.Remote.SSHKey)
+	setString("ssh-known-hosts", c.Remote.KnownHosts)
+	setString("timeout", c.Remote.Timeout)
+	setBool("tag", c.Tag.Enabled)
+	setBool("push-tag", c.Tag.Push)
+	setString("git-user", c.Tag.GitUser)
+	setString("git-email", c.Tag.GitEmail)
+	setString("tag-message", c.Tag.Message)
+	setBool("tag-lightweight", c.Tag.Lightweight)
+	setString("sign-tag", c.Tag.Sign)
+	setString("signing-key", c.Tag.SigningKey)
+	setString("verify-tag", c.Tag.VerifyKeys)
+
+	return flags
+}
+
+func (c Config) sortedBumpRuleTypes() []string {
+	types := make([]string, 0, len(c.BumpRules))
+	for commitType := range c.BumpRules {
+		types = append(types, commitType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// String returns the rule formatted as `pattern=policy[:channel]`
+func (r BranchRule) String() string {
+	s := r.Branch + "=" + r.Policy
+	if r.Channel != "" {
+		s += ":" + r.Channel
+	}
+	return s
+}
+
+// String returns the component formatted as `name=path[:tagPrefix]`
+func (c Component) String() string {
+	s := c.Name + "=" + c.Path
+	if c.TagPrefix != "" {
+		s += ":" + c.TagPrefix
+	}
+	return s
+}
+
+func keyError(key string, err error) error {
+	return fmt.Errorf("invalid value for key %q: %w", key, err)
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		content          *string
+		expectedFlags    map[string]string
+		expectedErrorMsg string
+	}{
+		{
+			name:          "no configuration file",
+			expectedFlags: nil,
+		},
+		{
+			name:          "empty configuration file",
+			content:       stringPtr(""),
+			expectedFlags: map[string]string{},
+		},
+		{
+			name: "full configuration file",
+			content: stringPtr(`previousVersion: from-tag
+nextVersion: semantic:strip-prerelease
+output: json
+outputFormat: "{{.Major}}.{{.Minor}}"
+tagPrefix: ""
+bumpRules:
+  perf: minor
+  docs: none
+branchRules:
+  - branch: main
+    policy: release
+  - branch: "feature/*"
+    policy: prerelease
+    channel: alpha
+components:
+  - name: api
+    path: services/api
+  - name: web
+    path: web
+    tagPrefix: web-v
+promote: false
+updateFiles: auto
+changelog:
+  file: CHANGELOG.md
+fetchTags: true
+remoteTags: true
+reachableTags: true
+nearestTag: false
+remote:
+  timeout: 30s
+tag:
+  enabled: true
+  push: false
+  gitUser: bot
+  gitEmail: bot@example.com
+`),
+			expectedFlags: map[string]string{
+				"previous-version": "from-tag",
+				"next-version":     "semantic:strip-prerelease",
+				"output":           "json",
+				"output-format":    "{{.Major}}.{{.Minor}}",
+				"tag-prefix":       "",
+				"bump-rules":       "docs=none,perf=minor",
+				"branch-rules":     "main=release,feature/*=prerelease:alpha",
+				"components":       "api=services/api,web=web:web-v",
+				"promote":          "false",
+				"update-files":     "auto",
+				"changelog":        "CHANGELOG.md",
+				"fetch-tags":       "true",
+				"remote-tags":      "true",
+				"reachable-tags":   "true",
+				"nearest-tag":      "false",
+				"timeout":          "30s",
+				"tag":              "true",
+				"push-tag":         "false",
+				"git-user":         "bot",
+				"git-email":        "bot@example.com",
+			},
+		},
+		{
+			name:          "list of files to update",
+			content:       stringPtr("updateFiles:\n  - package.json\n  - Chart.yaml\n"),
+			expectedFlags: map[string]string{"update-files": "package.json,Chart.yaml"},
+		},
+		{
+			name:             "unknown key",
+			content:          stringPtr("nextVersion: semantic\nbumpRule:\n  perf: minor\n"),
+			expectedErrorMsg: "field bumpRule not found",
+		},
+		{
+			name:             "invalid output",
+			content:          stringPtr("output: yaml\n"),
+			expectedErrorMsg: `invalid value for key "output": must be text or json, not "yaml"`,
+		},
+		{
+			name:             "invalid timeout",
+			content:          stringPtr("remote:\n  timeout: 30\n"),
+			expectedErrorMsg: `invalid value for key "remote.timeout"`,
+		},
+		{
+			name:             "invalid bump rule",
+			content:          stringPtr("bumpRules:\n  perf: huge\n"),
+			expectedErrorMsg: `invalid value for key "bumpRules.perf"`,
+		},
+		{
+			name:             "invalid branch rule",
+			content:          stringPtr("branchRules:\n  - branch: main\n    policy: release\n  - branch: develop\n    policy: nightly\n"),
+			expectedErrorMsg: `invalid value for key "branchRules[1]"`,
+		},
+		{
+			name:             "invalid component",
+			content:          stringPtr("components:\n  - name: api\n"),
+			expectedErrorMsg: `invalid value for key "components[0]"`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			if test.content != nil {
+				err := os.WriteFile(filepath.Join(dir, FileName), []byte(*test.content), 0o600)
+				require.NoError(t, err)
+			}
+
+			cfg, err := Load(dir)
+			if test.expectedErrorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErrorMsg)
+				assert.Nil(t, cfg)
+				return
+			}
+			require.NoError(t, err)
+			if test.expectedFlags == nil {
+				assert.Nil(t, cfg)
+				return
+			}
+			require.NotNil(t, cfg)
+			assert.Equal(t, test.expectedFlags, cfg.Flags())
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
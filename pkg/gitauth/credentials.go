@@ -0,0 +1,225 @@
+package gitauth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// This file implements the git credential helpers: https://git-scm.com/docs/gitcredentials
+
+// credentialHelpers returns the `credential.helper` values of the global and repository git configs, in order,
+// including the `credential.<url>.helper` values of the URLs matching the endpoint
+func credentialHelpers(repo *git.Repository, endpoint *transport.Endpoint) []string {
+	var helpers []string
+	if global, err := config.LoadConfig(config.GlobalScope); err == nil && global.Raw != nil {
+		helpers = append(helpers, configHelpers(global.Raw, endpoint)...)
+	}
+	if local, err := repo.Config(); err == nil && local.Raw != nil {
+		helpers = append(helpers, configHelpers(local.Raw, endpoint)...)
+	}
+
+	// an empty helper resets the list of helpers
+	for i := len(helpers) - 1; i >= 0; i-- {
+		if strings.TrimSpace(helpers[i]) == "" {
+			return helpers[i+1:]
+		}
+	}
+	return helpers
+}
+
+// configHelpers returns the helpers of the credential section of a git config, and of its subsections matching the endpoint
+func configHelpers(cfg *format.Config, endpoint *transport.Endpoint) []string {
+	section := cfg.Section("credential")
+	helpers := section.Options.GetAll("helper")
+	for _, subsection := range section.Subsections {
+		if matchURL(subsection.Name, endpoint) {
+			helpers = append(helpers, subsection.Options.GetAll("helper")...)
+		}
+	}
+	return helpers
+}
+
+// matchURL returns true if the URL of a credential subsection matches the endpoint, like git does:
+// the same protocol, host - where a * label matches any label - and port, the same user if any, and the path as a prefix
+func matchURL(rawURL string, endpoint *transport.Endpoint) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || u.Scheme != endpoint.Protocol {
+		return false
+	}
+	if u.User != nil && u.User.Username() != endpoint.User {
+		return false
+	}
+
+	labels, endpointLabels := strings.Split(u.Hostname(), "."), strings.Split(endpoint.Host, ".")
+	if len(labels) != len(endpointLabels) {
+		return false
+	}
+	for i, label := range labels {
+		if label != "*" && !strings.EqualFold(label, endpointLabels[i]) {
+			return false
+		}
+	}
+	if port := u.Port(); (port == "" && endpoint.Port != 0) || (port != "" && port != strconv.Itoa(endpoint.Port)) {
+		return false
+	}
+
+	prefix, path := strings.Trim(u.Path, "/"), strings.Trim(endpoint.Path, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// fillCredentials returns the first credentials found by the helpers for the given endpoint.
+// Like git, a helper failing is logged and skipped: it means no credentials, not an error.
+func fillCredentials(ctx context.Context, helpers []string, endpoint *transport.Endpoint) (string, string) {
+	for _, helper := range helpers {
+		var (
+			credentials map[string]string
+			err         error
+		)
+		if name, args := parseHelper(helper); name == "store" {
+			credentials, err = storedCredentials(storeFiles(args), endpoint)
+		} else {
+			credentials, err = runHelper(ctx, helper, endpoint)
+		}
+		if err != nil {
+			log.Logger().Warnf("Failed to get the credentials from the git credential helper %q: %s", helper, err.Error())
+			continue
+		}
+		if credentials["password"] != "" {
+			return credentials["username"], credentials["password"]
+		}
+	}
+	return "", ""
+}
+
+// parseHelper returns the name and the arguments of a helper
+func parseHelper(helper string) (string, []string) {
+	fields := strings.Fields(helper)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], fields[1:]
+}
+
+// storeFiles returns the files of the store helper:
+// the --file argument, or the ~/.git-credentials and $XDG_CONFIG_HOME/git/credentials files
+func storeFiles(args []string) []string {
+	for i, arg := range args {
+		if file, found := strings.CutPrefix(arg, "--file="); found {
+			return []string{expandHome(file)}
+		}
+		if arg == "--file" && i+1 < len(args) {
+			return []string{expandHome(args[i+1])}
+		}
+	}
+
+	var files []string
+	home, err := os.UserHomeDir()
+	if err == nil {
+		files = append(files, filepath.Join(home, ".git-credentials"))
+	}
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		files = append(files, filepath.Join(xdgConfigHome, "git", "credentials"))
+	} else if err == nil {
+		files = append(files, filepath.Join(home, ".config", "git", "credentials"))
+	}
+	return files
+}
+
+// storedCredentials returns the credentials of the first URL of the given files matching the endpoint
+func storedCredentials(files []string, endpoint *transport.Endpoint) (map[string]string, error) {
+	for _, file := range files {
+		content, err := os.ReadFile(file) // #nosec G304 -- credentials file of the git config
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			u, err := url.Parse(strings.TrimSpace(line))
+			if err != nil || u.User == nil {
+				continue
+			}
+			if u.Scheme != endpoint.Protocol || u.Host != endpointHost(endpoint) {
+				continue
+			}
+			if path := strings.Trim(u.Path, "/"); path != "" && path != strings.Trim(endpoint.Path, "/") {
+				continue
+			}
+			password, _ := u.User.Password()
+			log.Logger().Debugf("Found the credentials of %s in %s", endpointHost(endpoint), file)
+			return map[string]string{"username": u.User.Username(), "password": password}, nil
+		}
+	}
+	return nil, nil
+}
+
+// runHelper runs the `get` action of a helper, and returns the credentials.
+// A helper name - such as `cache` or `osxkeychain` - is run as `git credential-<name>`, to find it in the exec-path of git.
+func runHelper(ctx context.Context, helper string, endpoint *transport.Endpoint) (map[string]string, error) {
+	var cmd *exec.Cmd
+	switch name, args := parseHelper(helper); {
+	case strings.HasPrefix(helper, "!"):
+		cmd = exec.CommandContext(ctx, "sh", "-c", strings.TrimPrefix(helper, "!")+" get") // #nosec G204 -- helper of the git config
+	case filepath.IsAbs(name):
+		cmd = exec.CommandContext(ctx, name, append(args, "get")...) // #nosec G204 -- helper of the git config
+	default:
+		cmd = exec.CommandContext(ctx, "git", append([]string{"credential-" + name}, append(args, "get")...)...) // #nosec G204 -- helper of the git config
+	}
+
+	var stdin bytes.Buffer
+	fmt.Fprintf(&stdin, "protocol=%s\nhost=%s\n", endpoint.Protocol, endpointHost(endpoint))
+	if endpoint.User != "" {
+		fmt.Fprintf(&stdin, "username=%s\n", endpoint.User)
+	}
+	stdin.WriteString("\n")
+	cmd.Stdin = &stdin
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if key, value, found := strings.Cut(scanner.Text(), "="); found {
+			credentials[key] = value
+		}
+	}
+	return credentials, scanner.Err()
+}
+
+// endpointHost returns the host of the endpoint, with its port - if not the default one
+func endpointHost(endpoint *transport.Endpoint) string {
+	if endpoint.Port == 0 {
+		return endpoint.Host
+	}
+	return fmt.Sprintf("%s:%d", endpoint.Host, endpoint.Port)
+}
+
+// expandHome replaces the ~ prefix of a path with the home directory
+func expandHome(path string) string {
+	if rest, found := strings.CutPrefix(path, "~/"); found {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,192 @@
+package gitauth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// DefaultRemote is the name of the default git remote
+const DefaultRemote = "origin"
+
+// anonymousRemote is the name of the remote created for a remote URL
+const anonymousRemote = "anonymous"
+
+// Config is the git remote used to fetch and push the tags, and how to authenticate to it.
+type Config struct {
+	// Remote is the name or the URL of the git remote. Default to origin.
+	Remote string
+	// Token and User are used for the HTTP basic auth. If the token is not set, the git credential helpers are used.
+	Token string
+	User  string
+	// SSHKeyFile is the private key file used for the SSH auth. If neither the file nor the key is set, the ssh-agent is used.
+	SSHKeyFile string
+	// SSHKey is the private key used for the SSH auth.
+	SSHKey           []byte
+	SSHKeyPassphrase string
+	// KnownHostsFile is the known_hosts file used to check the SSH host keys.
+	// Default to the SSH_KNOWN_HOSTS env var, or the ~/.ssh/known_hosts file.
+	KnownHostsFile string
+	// InsecureIgnoreHostKey disables the check of the SSH host keys
+	InsecureIgnoreHostKey bool
+	// Timeout cancels each network operation on the remote - such as fetching or pushing the tags - if it takes longer. No timeout if zero.
+	Timeout time.Duration
+}
+
+// FromEnv returns the configuration from the env vars:
+// GIT_TOKEN, GIT_USER, GIT_SSH_KEY and GIT_SSH_KEY_PASSPHRASE
+func FromEnv() Config {
+	return Config{
+		Token:            os.Getenv("GIT_TOKEN"),
+		User:             os.Getenv("GIT_USER"),
+		SSHKey:           []byte(os.Getenv("GIT_SSH_KEY")),
+		SSHKeyPassphrase: os.Getenv("GIT_SSH_KEY_PASSPHRASE"),
+	}
+}
+
+// RemoteName returns the name of the remote - or its URL
+func (c Config) RemoteName() string {
+	if c.Remote == "" {
+		return DefaultRemote
+	}
+	return c.Remote
+}
+
+// Context returns the context of a network operation on the remote: canceled after the timeout - if set
+func (c Config) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.Timeout)
+}
+
+// OpenRemote returns the git remote: either the remote of the repository with this name, or a new remote for this URL
+func (c Config) OpenRemote(repo *git.Repository) (*git.Remote, error) {
+	name := c.RemoteName()
+	if !IsURL(name) {
+		remote, err := repo.Remote(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the %s remote: %w", name, err)
+		}
+		return remote, nil
+	}
+	return git.NewRemote(repo.Storer, &config.RemoteConfig{
+		Name: anonymousRemote,
+		URLs: []string{name},
+	}), nil
+}
+
+// Auth returns the auth method for the given remote: SSH or HTTP auth, depending on the protocol of the remote URL.
+// It returns nil if no auth is needed - or if no credentials are found.
+// The context bounds the git credential helpers, with the timeout of the config.
+func (c Config) Auth(ctx context.Context, repo *git.Repository, remote *git.Remote) (transport.AuthMethod, error) {
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("the %s remote has no URL", remote.Config().Name)
+	}
+	endpoint, err := transport.NewEndpoint(urls[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL for the %s remote: %w", remote.Config().Name, err)
+	}
+
+	switch endpoint.Protocol {
+	case "ssh":
+		return c.sshAuth(endpoint)
+	case "http", "https":
+		return c.httpAuth(ctx, repo, endpoint)
+	default:
+		return nil, nil
+	}
+}
+
+func (c Config) sshAuth(endpoint *transport.Endpoint) (transport.AuthMethod, error) {
+	user := endpoint.User
+	if user == "" {
+		user = "git"
+	}
+
+	var hostKeyCallback gossh.HostKeyCallback
+	switch {
+	case c.InsecureIgnoreHostKey:
+		log.Logger().Warnf("The host key of %s will not be checked", endpoint.Host)
+		hostKeyCallback = gossh.InsecureIgnoreHostKey() // #nosec G106 -- explicitly requested
+	case c.KnownHostsFile != "":
+		callback, err := ssh.NewKnownHostsCallback(c.KnownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the known hosts file %s: %w", c.KnownHostsFile, err)
+		}
+		hostKeyCallback = callback
+	}
+
+	switch {
+	case c.SSHKeyFile != "":
+		log.Logger().Debugf("Using the SSH key %s for %s@%s", c.SSHKeyFile, user, endpoint.Host)
+		auth, err := ssh.NewPublicKeysFromFile(user, c.SSHKeyFile, c.SSHKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the SSH key %s: %w", c.SSHKeyFile, err)
+		}
+		auth.HostKeyCallback = hostKeyCallback
+		return auth, nil
+	case len(c.SSHKey) > 0:
+		log.Logger().Debugf("Using the SSH key from the env for %s@%s", user, endpoint.Host)
+		auth, err := ssh.NewPublicKeys(user, c.SSHKey, c.SSHKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the SSH key: %w", err)
+		}
+		auth.HostKeyCallback = hostKeyCallback
+		return auth, nil
+	default:
+		log.Logger().Debugf("Using the ssh-agent for %s@%s", user, endpoint.Host)
+		auth, err := ssh.NewSSHAgentAuth(user)
+		if err != nil {
+			return nil, fmt.Errorf("no SSH key set, and failed to use the ssh-agent: %w", err)
+		}
+		auth.HostKeyCallback = hostKeyCallback
+		return auth, nil
+	}
+}
+
+func (c Config) httpAuth(ctx context.Context, repo *git.Repository, endpoint *transport.Endpoint) (transport.AuthMethod, error) {
+	if c.Token != "" {
+		user := c.User
+		if user == "" {
+			user = "abc123" // yes, this can be anything except an empty string
+		}
+		return &http.BasicAuth{
+			Username: user,
+			Password: c.Token,
+		}, nil
+	}
+	if endpoint.User != "" && endpoint.Password != "" {
+		// the credentials are in the URL
+		return nil, nil
+	}
+
+	ctx, cancel := c.Context(ctx)
+	defer cancel()
+	user, password := fillCredentials(ctx, credentialHelpers(repo, endpoint), endpoint)
+	if password == "" {
+		log.Logger().Debugf("No credentials found for %s", endpoint.Host)
+		return nil, nil
+	}
+	log.Logger().Debugf("Using the credentials of %s from the git credential helpers for %s", user, endpoint.Host)
+	return &http.BasicAuth{
+		Username: user,
+		Password: password,
+	}, nil
+}
+
+// IsURL returns true if the given remote is a URL - such as `https://host/repo.git` or `git@host:repo.git` - and not a remote name
+func IsURL(remote string) bool {
+	return strings.Contains(remote, "://") || strings.Contains(remote, "@") || strings.HasPrefix(remote, "/")
+}
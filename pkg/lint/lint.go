@@ -0,0 +1,107 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
+	"github.com/zbindenren/cc"
+)
+
+// DefaultTypes are the commit types allowed if none are set - the types of the Angular convention
+var DefaultTypes = []string{"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test"}
+
+// AnyType allows all the commit types, or all the scopes
+const AnyType = "*"
+
+var (
+	// ignoredRegexp matches the headlines of the commits which aren't checked: the merge commits, and the fixup or squash commits which will be squashed
+	ignoredRegexp = regexp.MustCompile(`^(Merge |fixup! |squash! |amend! )`)
+)
+
+// Rules are the rules the commit messages must follow, on top of the conventional commits specification
+type Rules struct {
+	// Types are the allowed commit types - default to DefaultTypes
+	Types []string
+	// Scopes are the allowed scopes - any scope if empty. A commit without a scope is always allowed.
+	Scopes []string
+}
+
+// Result is the result of the check of a commit
+type Result struct {
+	// Hash is empty for the commit headlines passed as a string
+	Hash     string
+	Headline string
+	// Problem explains why the commit doesn't follow the rules - empty if it does
+	Problem string
+	// Ignored is true for the commits which aren't checked, such as the merge commits
+	Ignored bool
+}
+
+// Check checks that each commit follows the conventional commits specification and the rules, and returns their results
+func (r Rules) Check(commits []semantic.Commit) []Result {
+	types := r.Types
+	if len(types) == 0 {
+		types = DefaultTypes
+	}
+
+	results := make([]Result, 0, len(commits))
+	for _, commit := range commits {
+		result := Result{
+			Hash:     commit.Hash,
+			Headline: strings.TrimSpace(strings.SplitN(commit.Message, "\n", 2)[0]),
+		}
+		switch {
+		case ignoredRegexp.MatchString(result.Headline):
+			result.Ignored = true
+		case strings.TrimSpace(commit.Message) == "":
+			result.Problem = "empty commit message"
+		default:
+			result.Problem = r.problem(commit, types)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// problem returns why the commit doesn't follow the rules - or an empty string if it does
+func (r Rules) problem(commit semantic.Commit, types []string) string {
+	conventional := commit.Conventional
+	if conventional == nil {
+		var err error
+		conventional, err = cc.Parse(commit.Message)
+		if err != nil {
+			return fmt.Sprintf("not a conventional commit: %v", err)
+		}
+	}
+
+	if !allowed(types, conventional.Header.Type) {
+		return fmt.Sprintf("type %q is not allowed - must be one of %s", conventional.Header.Type, strings.Join(types, ", "))
+	}
+	if conventional.Header.Scope != "" && len(r.Scopes) > 0 && !allowed(r.Scopes, conventional.Header.Scope) {
+		return fmt.Sprintf("scope %q is not allowed - must be one of %s", conventional.Header.Scope, strings.Join(r.Scopes, ", "))
+	}
+	return ""
+}
+
+// allowed returns true if the value - case-insensitive - is one of the allowed values, or if any value is allowed
+func allowed(values []string, value string) bool {
+	for _, v := range values {
+		if v == AnyType || strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Failed returns the number of commits which don't follow the rules
+func Failed(results []Result) int {
+	var failed int
+	for _, result := range results {
+		if result.Problem != "" {
+			failed++
+		}
+	}
+	return failed
+}
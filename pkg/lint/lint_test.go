@@ -0,0 +1,110 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		rules           Rules
+		message         string
+		expectedProblem string
+		expectedIgnored bool
+	}{
+		{
+			name:    "conventional commit",
+			message: "feat(api): a new endpoint\n\nCloses #12",
+		},
+		{
+			name:    "breaking change",
+			message: "refactor!: drop the v1 API",
+		},
+		{
+			name:            "not a conventional commit",
+			message:         "fix stuff",
+			expectedProblem: "not a conventional commit: ",
+		},
+		{
+			name:            "empty message",
+			message:         " ",
+			expectedProblem: "empty commit message",
+		},
+		{
+			name:            "unknown type",
+			message:         "feature: a new endpoint",
+			expectedProblem: `type "feature" is not allowed - must be one of build, chore, ci, docs, feat, fix, perf, refactor, revert, style, test`,
+		},
+		{
+			name:    "case-insensitive type",
+			message: "Fix: a bug",
+		},
+		{
+			name:    "custom type",
+			rules:   Rules{Types: []string{"feat", "fix", "deps"}},
+			message: "deps: bump go-git",
+		},
+		{
+			name:            "type not in the custom types",
+			rules:           Rules{Types: []string{"feat", "fix"}},
+			message:         "chore: cleanup",
+			expectedProblem: `type "chore" is not allowed - must be one of feat, fix`,
+		},
+		{
+			name:    "any type",
+			rules:   Rules{Types: []string{AnyType}},
+			message: "whatever: cleanup",
+		},
+		{
+			name:    "allowed scope",
+			rules:   Rules{Scopes: []string{"api", "cli"}},
+			message: "fix(cli): a bug",
+		},
+		{
+			name:    "no scope",
+			rules:   Rules{Scopes: []string{"api", "cli"}},
+			message: "fix: a bug",
+		},
+		{
+			name:            "scope not allowed",
+			rules:           Rules{Scopes: []string{"api", "cli"}},
+			message:         "fix(ui): a bug",
+			expectedProblem: `scope "ui" is not allowed - must be one of api, cli`,
+		},
+		{
+			name:            "merge commit",
+			message:         "Merge pull request #42 from owner/branch",
+			expectedIgnored: true,
+		},
+		{
+			name:            "fixup commit",
+			message:         "fixup! feat: a new endpoint",
+			expectedIgnored: true,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			results := test.rules.Check([]semantic.Commit{{Hash: "abc123", Message: test.message}})
+			assert.Len(t, results, 1)
+			actual := results[0]
+			assert.Equal(t, "abc123", actual.Hash)
+			assert.Equal(t, test.expectedIgnored, actual.Ignored)
+			if test.expectedProblem == "" {
+				assert.Empty(t, actual.Problem)
+				assert.Equal(t, 0, Failed(results))
+				return
+			}
+			assert.Contains(t, actual.Problem, test.expectedProblem)
+			assert.Equal(t, 1, Failed(results))
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package release
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/branch"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/gitauth"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/fromtag"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
+)
+
+// Reader is the typed options of a strategy reading the previous version - such as FromTag - registered with RegisterReader.
+// ReaderName returns the name of the strategy, the same for any value of the options.
+type Reader interface {
+	ReaderName() string
+}
+
+// Bumper is the typed options of a strategy calculating the next version - such as Increment - registered with RegisterBumper.
+// BumperName returns the name of the strategy, the same for any value of the options.
+type Bumper interface {
+	BumperName() string
+}
+
+// ReaderFactory creates the strategy reading the previous version, from its options - such as FromTag{Pattern: "^v1"} -
+// and the options of the scope to version
+type ReaderFactory[R Reader] func(r R, o StrategyOptions) (strategy.VersionReader, error)
+
+// BumperFactory creates the strategy calculating the next version, from its options - such as Increment{Component: "minor"} -
+// and the options of the scope to version
+type BumperFactory[B Bumper] func(b B, o StrategyOptions) (strategy.VersionBumper, error)
+
+type (
+	readerFactory func(r Reader, o StrategyOptions) (strategy.VersionReader, error)
+	bumperFactory func(b Bumper, o StrategyOptions) (strategy.VersionBumper, error)
+)
+
+var (
+	registryMutex sync.RWMutex
+	readers       = map[string]readerFactory{}
+	bumpers       = map[string]bumperFactory{}
+)
+
+// RegisterReader registers a strategy reading the previous version, used with its R options as the PreviousVersion option.
+// It panics if the name of R is empty or already registered, or if the factory is nil.
+func RegisterReader[R Reader](factory ReaderFactory[R]) {
+	var options R
+	name := options.ReaderName()
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	checkName(name, factory == nil)
+	if _, found := readers[name]; found {
+		panic(fmt.Sprintf("release: version reader %q already registered", name))
+	}
+	readers[name] = func(r Reader, o StrategyOptions) (strategy.VersionReader, error) {
+		typed, ok := r.(R)
+		if !ok {
+			return nil, fmt.Errorf("the %q version reader is registered with the %T options, not %T", name, options, r)
+		}
+		return factory(typed, o)
+	}
+}
+
+// RegisterBumper registers a strategy calculating the next version, used with its B options as the NextVersion option.
+// It panics if the name of B is empty or already registered, or if the factory is nil.
+func RegisterBumper[B Bumper](factory BumperFactory[B]) {
+	var options B
+	name := options.BumperName()
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	checkName(name, factory == nil)
+	if _, found := bumpers[name]; found {
+		panic(fmt.Sprintf("release: version bumper %q already registered", name))
+	}
+	bumpers[name] = func(b Bumper, o StrategyOptions) (strategy.VersionBumper, error) {
+		typed, ok := b.(B)
+		if !ok {
+			return nil, fmt.Errorf("the %q version bumper is registered with the %T options, not %T", name, options, b)
+		}
+		return factory(typed, o)
+	}
+}
+
+func checkName(name string, nilFactory bool) {
+	if name == "" {
+		panic(fmt.Sprintf("release: invalid strategy name %q", name))
+	}
+	if nilFactory {
+		panic(fmt.Sprintf("release: nil factory for strategy %q", name))
+	}
+}
+
+// Readers returns the sorted names of the registered strategies reading the previous version
+func Readers() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	return sortedNames(readers)
+}
+
+// Bumpers returns the sorted names of the registered strategies calculating the next version
+func Bumpers() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	return sortedNames(bumpers)
+}
+
+func sortedNames[F any](factories map[string]F) []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupReader(name string) (readerFactory, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	factory, found := readers[name]
+	return factory, found
+}
+
+func lookupBumper(name string) (bumperFactory, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	factory, found := bumpers[name]
+	return factory, found
+}
+
+// StrategyOptions are the options of the scope to version - the whole repository or a component of a monorepo -
+// passed to the strategy factories
+type StrategyOptions struct {
+	// Dir is the directory of the git repository
+	Dir string
+	// FileDir is the directory used by the file-based strategies: the directory of the component, or Dir
+	FileDir string
+	// Path restricts the commits used by the semantic strategy, relative to the root of the repository
+	Path string
+	// TagPrefix is the prefix of the tags of the scope, such as `v`
+	TagPrefix string
+	// Component is the name of the component of a monorepo - empty for the whole repository.
+	// A component only uses its own tags.
+	Component string
+	// Line is only set on a maintenance branch: only the tags of the release line are used
+	Line            *branch.Line
+	CommitHeadlines string
+	BumpRules       semantic.Rules
+	FetchTags       bool
+	RemoteTags      bool
+	ReachableTags   bool
+	NearestTag      bool
+	GitAuth         gitauth.Config
+	// Decision is filled by the strategies
+	Decision *strategy.Decision
+}
+
+// FromTag returns the from-tag strategy for the scope:
+// the prefix of the scope is removed from its tags, and a component only uses its own tags - matching the tag pattern, if any.
+func (o StrategyOptions) FromTag(tagPattern string) fromtag.Strategy {
+	s := fromtag.Strategy{
+		Dir:           o.Dir,
+		TagPattern:    tagPattern,
+		TagPrefix:     o.TagPrefix,
+		PrefixedOnly:  o.Component != "",
+		FetchTags:     o.FetchTags,
+		RemoteTags:    o.RemoteTags,
+		ReachableOnly: o.ReachableTags,
+		Nearest:       o.NearestTag,
+		GitAuth:       o.GitAuth,
+		Decision:      o.Decision,
+	}
+	if o.Line != nil && tagPattern == "" {
+		// only the tags of the release line of the maintenance branch
+		s.TagPattern = "^" + regexp.QuoteMeta(o.TagPrefix) + o.Line.TagPattern()
+	}
+	return s
+}
+
+// Semantic returns the semantic strategy for the scope, with the options of the strategy
+func (o StrategyOptions) Semantic(s Semantic) semantic.Strategy {
+	var previousTag string
+	if o.Decision != nil {
+		// set by the strategy which read the previous version
+		previousTag = o.Decision.PreviousTag
+	}
+	return semantic.Strategy{
+		Dir:                   o.Dir,
+		StripPrerelease:       s.StripPrerelease,
+		CommitHeadlinesString: o.CommitHeadlines,
+		TagPrefix:             o.TagPrefix,
+		PreviousTag:           previousTag,
+		Rules:                 o.BumpRules,
+		Path:                  o.Path,
+		FirstParent:           s.FirstParent,
+		Decision:              o.Decision,
+	}
+}
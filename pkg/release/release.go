@@ -0,0 +1,245 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/branch"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/component"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/gitauth"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/prerelease"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+var ErrInvalidOptions = errors.New("invalid options")
+
+// Options are the options used to calculate the next version - the same as the flags of the CLI
+type Options struct {
+	// Dir is the directory of the git repository - default to the current working directory
+	Dir string
+	// PreviousVersion is the strategy reading the previous version, such as FromTag{Pattern: "^v1"} or Manual{Version: "1.2.3"} - default to Auto
+	PreviousVersion Reader
+	// NextVersion is the strategy calculating the next version, such as Semantic{FirstParent: true} or Increment{Component: "minor"} - default to Auto
+	NextVersion Bumper
+	// TagPrefix is the prefix of the tags, such as `v`. It is ignored for a component, which has its own tag prefix.
+	TagPrefix string
+	// Component restricts the tags and the commits to a component of a monorepo - nil for the whole repository
+	Component *component.Component
+	// Line is the release line of a maintenance branch - nil otherwise
+	Line *branch.Line
+	// Prerelease is the pre-release channel of the next version, such as `rc` - empty for a release
+	Prerelease string
+	// Promote releases the previous pre-release as a release
+	Promote bool
+	// CommitHeadlines are used instead of the commits of the repository
+	CommitHeadlines string
+	BumpRules       semantic.Rules
+	FetchTags       bool
+	RemoteTags      bool
+	ReachableTags   bool
+	NearestTag      bool
+	// GitAuth is the remote the tags are read from, and its authentication
+	GitAuth gitauth.Config
+	// PreviousOnly only reads the previous version, without calculating the next version
+	PreviousOnly bool
+	// VerifyPreviousTag - if set - is called with the tag of the previous version before anything is calculated from it,
+	// such as to verify its signature. The tag is empty if the previous version wasn't read from a tag.
+	VerifyPreviousTag func(tag string) error
+}
+
+// Result is the result of the calculation of the next version
+type Result struct {
+	PreviousVersion *semver.Version
+	// NextVersion is nil if no release is needed, or if only the previous version was read
+	NextVersion *semver.Version
+	// Decision records how the versions were calculated
+	Decision *strategy.Decision
+}
+
+// Release returns true if a new version should be released
+func (r Result) Release() bool {
+	return r.NextVersion != nil
+}
+
+// Validate checks that the options can be used together
+func (o Options) Validate() error {
+	if o.Prerelease != "" && o.Promote {
+		return fmt.Errorf("%w: the prerelease and promote options can't be used together", ErrInvalidOptions)
+	}
+	if _, describe := o.NextVersion.(Describe); describe && (o.Prerelease != "" || o.Promote) {
+		return fmt.Errorf("%w: the prerelease and promote options can't be used with the describe strategy", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// Compute reads the previous version, and calculates the next version with the registered strategies.
+// It doesn't release anything: no file is written and no tag is created.
+// The context cancels the git operations, on top of the timeout of the network operations set in the GitAuth options.
+func Compute(ctx context.Context, opts Options) (Result, error) {
+	if err := opts.Validate(); err != nil {
+		return Result{}, err
+	}
+	o, err := opts.StrategyOptions()
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	reader, err := newReader(opts.previousVersion(), o)
+	if err != nil {
+		return Result{}, err
+	}
+	previousVersion, err := reader.ReadVersion(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read previous version using the %q strategy: %w", opts.previousVersion().ReaderName(), err)
+	}
+	log.Logger().Debugf("Previous version%s: %s", description(opts), previousVersion.String())
+
+	if opts.VerifyPreviousTag != nil {
+		if err := opts.VerifyPreviousTag(o.Decision.PreviousTag); err != nil {
+			return Result{}, fmt.Errorf("failed to verify the tag of the previous version %s: %w", previousVersion.String(), err)
+		}
+	}
+
+	r := Result{PreviousVersion: previousVersion, Decision: o.Decision}
+	if opts.PreviousOnly {
+		return r, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	bumper, err := newBumper(opts, o)
+	if err != nil {
+		return Result{}, err
+	}
+	nextVersion, err := bumper.BumpVersion(ctx, *previousVersion)
+	if errors.Is(err, semantic.ErrNoRelease) {
+		log.Logger().Debugf("No release needed%s since version %s", description(opts), previousVersion.String())
+		return r, nil
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to bump version using the %q strategy: %w", opts.nextVersion().BumperName(), err)
+	}
+	log.Logger().Debugf("Next version%s: %s", description(opts), nextVersion.String())
+
+	r.NextVersion = nextVersion
+	return r, nil
+}
+
+// StrategyOptions returns the options of the scope to version passed to the strategy factories, with a new decision
+func (o Options) StrategyOptions() (StrategyOptions, error) {
+	dir := o.Dir
+	if dir == "" {
+		var err error
+		dir, err = os.Getwd()
+		if err != nil {
+			return StrategyOptions{}, fmt.Errorf("failed to get current working directory: %w", err)
+		}
+	}
+
+	s := StrategyOptions{
+		Dir:             dir,
+		FileDir:         dir,
+		TagPrefix:       o.TagPrefix,
+		Line:            o.Line,
+		CommitHeadlines: o.CommitHeadlines,
+		BumpRules:       o.BumpRules,
+		FetchTags:       o.FetchTags,
+		RemoteTags:      o.RemoteTags,
+		ReachableTags:   o.ReachableTags,
+		NearestTag:      o.NearestTag,
+		GitAuth:         o.GitAuth,
+		Decision:        &strategy.Decision{},
+	}
+	if c := o.Component; c != nil {
+		s.FileDir = filepath.Join(dir, filepath.FromSlash(c.Path))
+		s.Path = c.Path
+		s.TagPrefix = c.TagPrefix
+		s.Component = c.Name
+	}
+	return s, nil
+}
+
+// previousVersion returns the strategy reading the previous version - default to Auto
+func (o Options) previousVersion() Reader {
+	if o.PreviousVersion == nil {
+		return Auto{}
+	}
+	return o.PreviousVersion
+}
+
+// nextVersion returns the strategy calculating the next version - default to Auto
+func (o Options) nextVersion() Bumper {
+	if o.NextVersion == nil {
+		return Auto{}
+	}
+	return o.NextVersion
+}
+
+// newReader returns the registered strategy reading the previous version
+func newReader(previousVersion Reader, o StrategyOptions) (strategy.VersionReader, error) {
+	name := previousVersion.ReaderName()
+	factory, found := lookupReader(name)
+	if !found {
+		return nil, fmt.Errorf("no %q version reader registered", name)
+	}
+	log.Logger().Debugf("Using %q version reader (with %+v)", name, previousVersion)
+	reader, err := factory(previousVersion, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the %q version reader: %w", name, err)
+	}
+	return reader, nil
+}
+
+// newBumper returns the registered strategy calculating the next version,
+// wrapped by the maintenance and prerelease strategies if needed
+func newBumper(opts Options, o StrategyOptions) (strategy.VersionBumper, error) {
+	nextVersion := opts.nextVersion()
+	name := nextVersion.BumperName()
+	factory, found := lookupBumper(name)
+	if !found {
+		return nil, fmt.Errorf("no %q version bumper registered", name)
+	}
+	log.Logger().Debugf("Using %q version bumper (with %+v)", name, nextVersion)
+	bumper, err := factory(nextVersion, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the %q version bumper: %w", name, err)
+	}
+
+	if _, describe := nextVersion.(Describe); describe {
+		// the development version is already a pre-release, and the describe strategy wraps the maintenance strategy itself
+		return bumper, nil
+	}
+	bumper = o.Maintenance(bumper)
+
+	if opts.Prerelease != "" || opts.Promote {
+		log.Logger().Debugf("Using the prerelease version bumper (with channel %q)", opts.Prerelease)
+		bumper = prerelease.Strategy{
+			Dir:        o.Dir,
+			TagPrefix:  o.TagPrefix,
+			Channel:    opts.Prerelease,
+			Bumper:     bumper,
+			RemoteTags: o.RemoteTags,
+			GitAuth:    o.GitAuth,
+			Decision:   o.Decision,
+		}
+	}
+	return bumper, nil
+}
+
+// description returns the description of the scope for the logs: empty for the whole repository
+func description(o Options) string {
+	if o.Component == nil {
+		return ""
+	}
+	return " of component " + o.Component.Name
+}
@@ -0,0 +1,299 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/branch"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/component"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFixed are the options of a custom strategy registered by the tests, which always returns its version
+type testFixed struct {
+	Version string
+}
+
+func (testFixed) BumperName() string { return "test-fixed" }
+
+// testOtherFixed are the options of another strategy with the name of the registered one
+type testOtherFixed struct{}
+
+func (testOtherFixed) BumperName() string { return "test-fixed" }
+
+// testUnregistered are the options of a strategy which isn't registered
+type testUnregistered struct{}
+
+func (testUnregistered) ReaderName() string { return "test-unregistered" }
+
+// testUnnamed are the options of a strategy without a name
+type testUnnamed struct{}
+
+func (testUnnamed) ReaderName() string { return "" }
+
+// fixedBumper is the custom strategy registered by the tests
+type fixedBumper struct {
+	version  string
+	decision *strategy.Decision
+}
+
+func (b fixedBumper) BumpVersion(_ context.Context, _ semver.Version) (*semver.Version, error) {
+	b.decision.SetBump("fixed", "Registered by the test")
+	return semver.NewVersion(b.version)
+}
+
+func init() {
+	RegisterBumper(func(b testFixed, o StrategyOptions) (strategy.VersionBumper, error) {
+		return fixedBumper{version: b.Version, decision: o.Decision}, nil
+	})
+}
+
+func TestCompute(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		options          Options
+		expectedPrevious string
+		expectedNext     string
+		expectedTag      string
+		expectedBump     string
+		expectedErrorMsg string
+	}{
+		{
+			name:             "auto",
+			options:          Options{TagPrefix: "v"},
+			expectedPrevious: "v1.2.0",
+			expectedNext:     "1.3.0",
+			expectedTag:      "v1.2.0",
+			expectedBump:     "minor",
+		},
+		{
+			name:             "named strategies",
+			options:          Options{PreviousVersion: FromTag{Pattern: "^v1"}, NextVersion: Increment{Component: "major"}, TagPrefix: "v"},
+			expectedPrevious: "v1.2.0",
+			expectedNext:     "2.0.0",
+			expectedTag:      "v1.2.0",
+			expectedBump:     "major",
+		},
+		{
+			name:             "manual strategies without prefix",
+			options:          Options{PreviousVersion: Manual{Version: "1.0.0"}, NextVersion: Manual{Version: "1.0.1"}},
+			expectedPrevious: "1.0.0",
+			expectedNext:     "1.0.1",
+		},
+		{
+			name:             "previous version only",
+			options:          Options{TagPrefix: "v", PreviousOnly: true},
+			expectedPrevious: "v1.2.0",
+			expectedTag:      "v1.2.0",
+		},
+		{
+			name:             "no release needed",
+			options:          Options{Component: &component.Component{Name: "web", Path: "web", TagPrefix: "v"}},
+			expectedPrevious: "v1.2.0",
+			expectedTag:      "v1.2.0",
+			expectedBump:     "none",
+		},
+		{
+			name:             "prerelease",
+			options:          Options{TagPrefix: "v", Prerelease: "rc"},
+			expectedPrevious: "v1.2.0",
+			expectedNext:     "1.3.0-rc.1",
+			expectedTag:      "v1.2.0",
+		},
+		{
+			name:             "maintenance branch",
+			options:          Options{TagPrefix: "v", Line: &branch.Line{Major: 1, Minor: 1}},
+			expectedPrevious: "v1.1.0",
+			expectedNext:     "1.1.1",
+			expectedTag:      "v1.1.0",
+		},
+		{
+			name:             "component",
+			options:          Options{TagPrefix: "v", Component: &component.Component{Name: "api", Path: "api", TagPrefix: "api/v"}},
+			expectedPrevious: "0.1.0",
+			expectedNext:     "0.1.1",
+			expectedTag:      "api/v0.1.0",
+			expectedBump:     "patch",
+		},
+		{
+			name:             "component with tag pattern",
+			options:          Options{TagPrefix: "v", PreviousVersion: FromTag{Pattern: `0\.1\.`}, Component: &component.Component{Name: "api", Path: "api", TagPrefix: "api/v"}},
+			expectedPrevious: "0.1.0",
+			expectedNext:     "0.1.1",
+			expectedTag:      "api/v0.1.0",
+			expectedBump:     "patch",
+		},
+		{
+			name:             "component with tag pattern of other tags",
+			options:          Options{TagPrefix: "v", PreviousVersion: FromTag{Pattern: "^v1"}, Component: &component.Component{Name: "api", Path: "api", TagPrefix: "api/v"}},
+			expectedErrorMsg: `no semver tags with prefix "api/v" and pattern "^v1" found`,
+		},
+		{
+			name:             "registered strategy",
+			options:          Options{TagPrefix: "v", NextVersion: testFixed{Version: "5.0.0"}},
+			expectedPrevious: "v1.2.0",
+			expectedNext:     "5.0.0",
+			expectedTag:      "v1.2.0",
+			expectedBump:     "fixed",
+		},
+		{
+			name:             "invalid options",
+			options:          Options{NextVersion: Describe{}, Prerelease: "rc"},
+			expectedErrorMsg: "invalid options: the prerelease and promote options can't be used with the describe strategy",
+		},
+		{
+			name:             "invalid previous version",
+			options:          Options{PreviousVersion: Manual{Version: "not-a-version"}},
+			expectedErrorMsg: `failed to read previous version using the "manual" strategy`,
+		},
+		{
+			name:             "options of another strategy",
+			options:          Options{TagPrefix: "v", NextVersion: testOtherFixed{}},
+			expectedErrorMsg: `the "test-fixed" version bumper is registered with the release.testFixed options, not release.testOtherFixed`,
+		},
+		{
+			name:             "unregistered strategy",
+			options:          Options{PreviousVersion: testUnregistered{}},
+			expectedErrorMsg: `no "test-unregistered" version reader registered`,
+		},
+	}
+
+	dir := t.TempDir()
+	initRepository(t, dir)
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.options.Dir = dir
+			actual, err := Compute(context.Background(), test.options)
+			if test.expectedErrorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedPrevious, actual.PreviousVersion.Original())
+			if test.expectedNext == "" {
+				assert.Nil(t, actual.NextVersion)
+				assert.False(t, actual.Release())
+			} else {
+				require.NotNil(t, actual.NextVersion)
+				assert.Equal(t, test.expectedNext, actual.NextVersion.String())
+				assert.True(t, actual.Release())
+			}
+			require.NotNil(t, actual.Decision)
+			assert.Equal(t, test.expectedTag, actual.Decision.PreviousTag)
+			if test.expectedBump != "" {
+				assert.Equal(t, test.expectedBump, actual.Decision.Bump)
+			}
+		})
+	}
+}
+
+func TestComputeCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Compute(ctx, Options{PreviousVersion: Manual{Version: "1.0.0"}, NextVersion: Manual{Version: "1.0.1"}})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestComputeVerifyPreviousTag(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	initRepository(t, dir)
+	errUntrusted := errors.New("untrusted tag")
+
+	var verified []string
+	_, err := Compute(context.Background(), Options{
+		Dir:       dir,
+		TagPrefix: "v",
+		VerifyPreviousTag: func(tag string) error {
+			verified = append(verified, tag)
+			return errUntrusted
+		},
+		NextVersion: testFixed{Version: "not-a-version"},
+	})
+	// the next version is not calculated from an untrusted tag
+	require.ErrorIs(t, err, errUntrusted)
+	assert.Equal(t, []string{"v1.2.0"}, verified)
+
+	_, err = Compute(context.Background(), Options{
+		Dir:             dir,
+		PreviousVersion: Manual{Version: "1.0.0"},
+		VerifyPreviousTag: func(tag string) error {
+			verified = append(verified, tag)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.2.0", ""}, verified)
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"auto", "from-file", "from-tag", "manual"}, Readers())
+	assert.Equal(t, []string{"auto", "calver", "describe", "from-file", "increment", "manual", "semantic", "test-fixed"}, Bumpers())
+
+	assert.PanicsWithValue(t, `release: version bumper "auto" already registered`, func() {
+		RegisterBumper(func(Auto, StrategyOptions) (strategy.VersionBumper, error) { return nil, nil })
+	})
+	assert.PanicsWithValue(t, `release: invalid strategy name ""`, func() {
+		RegisterReader(func(testUnnamed, StrategyOptions) (strategy.VersionReader, error) { return nil, nil })
+	})
+	assert.PanicsWithValue(t, `release: nil factory for strategy "test-unregistered"`, func() {
+		RegisterReader[testUnregistered](nil)
+	})
+}
+
+// initRepository creates a git repository with a v1.1.0 tag on a fix commit, a v1.2.0 tag on a fix commit, a feat commit,
+// and the api/v0.1.0 tag of the api component followed by a fix commit in the api directory
+func initRepository(t *testing.T, dir string) {
+	t.Helper()
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commits := []struct {
+		file    string
+		message string
+		tags    []string
+	}{
+		{file: "file", message: "fix: one", tags: []string{"v1.1.0"}},
+		{file: "file", message: "fix: two", tags: []string{"v1.2.0", "api/v0.1.0"}},
+		{file: "file", message: "feat: three"},
+		{file: "api/file", message: "fix(api): four"},
+	}
+	for i, c := range commits {
+		path := filepath.Join(dir, filepath.FromSlash(c.file))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(c.message), 0o600))
+		_, err = w.Add(c.file)
+		require.NoError(t, err)
+		signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Date(2021, 1, 1, i, 0, 0, 0, time.UTC)}
+		hash, err := w.Commit(c.message, &git.CommitOptions{Author: signature, Committer: signature})
+		require.NoError(t, err)
+		for _, tag := range c.tags {
+			_, err = repo.CreateTag(tag, hash, nil)
+			require.NoError(t, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package release
+
+import (
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/auto"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/calver"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/describe"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/fromfile"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/increment"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/maintenance"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/manual"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+const (
+	// AutoStrategy is the default strategy: the previous version from the tags, and the next version from the conventional commits
+	AutoStrategy = "auto"
+	// ManualStrategy uses the given version, such as `1.2.3`
+	ManualStrategy = "manual"
+	// DescribeStrategy calculates development versions, such as `1.4.0-dev.3+g1a2b3c4`
+	DescribeStrategy = "describe"
+)
+
+// Auto is the default strategy: it reads the previous version from the tags, and calculates the next version
+// with the semantic strategy - or increments the patch component if there is no tag for the previous version
+type Auto struct {
+	// Semantic are the options of the semantic strategy calculating the next version
+	Semantic Semantic
+}
+
+func (Auto) ReaderName() string { return AutoStrategy }
+func (Auto) BumperName() string { return AutoStrategy }
+
+// FromTag reads the previous version from the git tags
+type FromTag struct {
+	// Pattern filters the tags, such as `^v1`. If it has a `version` named capture group, the group is used as the version.
+	Pattern string
+}
+
+func (FromTag) ReaderName() string { return "from-tag" }
+
+// FromFile reads the previous version - or the next version - from a file, such as a `package.json` or a `Chart.yaml`
+type FromFile struct {
+	// Path is the file, relative to the directory of the scope. If empty, the file is detected.
+	Path string
+}
+
+func (FromFile) ReaderName() string { return "from-file" }
+func (FromFile) BumperName() string { return "from-file" }
+
+// Manual uses the given version as the previous - or the next - version
+type Manual struct {
+	Version string
+}
+
+func (Manual) ReaderName() string { return ManualStrategy }
+func (Manual) BumperName() string { return ManualStrategy }
+
+// Semantic calculates the next version from the conventional commits since the previous version
+type Semantic struct {
+	// StripPrerelease removes the pre-release of the previous version before bumping it
+	StripPrerelease bool
+	// FirstParent only uses the first parent of the merge commits - like `git log --first-parent`
+	FirstParent bool
+}
+
+func (Semantic) BumperName() string { return "semantic" }
+
+// Increment increments a component of the previous version
+type Increment struct {
+	// Component is `major`, `minor` or `patch` - default to `patch`
+	Component string
+}
+
+func (Increment) BumperName() string { return "increment" }
+
+// CalVer calculates a calendar version from the current date
+type CalVer struct {
+	// Format is the calendar versioning format, such as `YYYY.MM.DD` - default to `YYYY.MM.MICRO`
+	Format string
+}
+
+func (CalVer) BumperName() string { return "calver" }
+
+// Describe calculates a development version from the distance to the previous version, such as `1.4.0-dev.3+g1a2b3c4`
+type Describe struct {
+	// Channel is the pre-release channel of the development versions - default to the one of the describe strategy
+	Channel string
+	// Semantic are the options of the semantic strategy calculating the next release
+	Semantic Semantic
+}
+
+func (Describe) BumperName() string { return DescribeStrategy }
+
+// the built-in strategies
+func init() {
+	RegisterReader(func(_ Auto, o StrategyOptions) (strategy.VersionReader, error) {
+		return auto.Strategy{FromTagStrategy: o.FromTag("")}, nil
+	})
+	RegisterReader(func(r FromTag, o StrategyOptions) (strategy.VersionReader, error) {
+		return o.FromTag(r.Pattern), nil
+	})
+	RegisterReader(func(r FromFile, o StrategyOptions) (strategy.VersionReader, error) {
+		return fromfile.Strategy{Dir: o.FileDir, FilePath: r.Path}, nil
+	})
+	RegisterReader(func(r Manual, _ StrategyOptions) (strategy.VersionReader, error) {
+		return manual.Strategy{Version: r.Version}, nil
+	})
+
+	RegisterBumper(func(b Auto, o StrategyOptions) (strategy.VersionBumper, error) {
+		return auto.Strategy{SemanticStrategy: o.Semantic(b.Semantic), Decision: o.Decision}, nil
+	})
+	RegisterBumper(func(b Semantic, o StrategyOptions) (strategy.VersionBumper, error) {
+		return o.Semantic(b), nil
+	})
+	RegisterBumper(func(b FromFile, o StrategyOptions) (strategy.VersionBumper, error) {
+		return fromfile.Strategy{Dir: o.FileDir, FilePath: b.Path}, nil
+	})
+	RegisterBumper(func(b Increment, o StrategyOptions) (strategy.VersionBumper, error) {
+		return increment.Strategy{ComponentToIncrement: b.Component, Decision: o.Decision}, nil
+	})
+	RegisterBumper(func(b CalVer, o StrategyOptions) (strategy.VersionBumper, error) {
+		return calver.Strategy{Format: b.Format, Decision: o.Decision}, nil
+	})
+	RegisterBumper(func(b Manual, _ StrategyOptions) (strategy.VersionBumper, error) {
+		return manual.Strategy{Version: b.Version}, nil
+	})
+	RegisterBumper(func(b Describe, o StrategyOptions) (strategy.VersionBumper, error) {
+		return describe.Strategy{
+			Dir:              o.Dir,
+			Channel:          b.Channel,
+			Bumper:           o.Maintenance(auto.Strategy{SemanticStrategy: o.Semantic(b.Semantic), Decision: o.Decision}),
+			SemanticStrategy: o.Semantic(b.Semantic),
+			Decision:         o.Decision,
+		}, nil
+	})
+}
+
+// Maintenance wraps the bumper with the maintenance strategy on a maintenance branch, so that the next version stays on the release line
+func (o StrategyOptions) Maintenance(bumper strategy.VersionBumper) strategy.VersionBumper {
+	if o.Line == nil {
+		return bumper
+	}
+	log.Logger().Debugf("Using the maintenance version bumper (with line %s)", o.Line)
+	return maintenance.Strategy{
+		Line:     *o.Line,
+		Bumper:   bumper,
+		Decision: o.Decision,
+	}
+}
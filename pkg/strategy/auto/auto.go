@@ -1,9 +1,12 @@
 package auto
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
 	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/fromtag"
 	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
@@ -12,16 +15,18 @@ import (
 type Strategy struct {
 	FromTagStrategy  fromtag.Strategy
 	SemanticStrategy semantic.Strategy
+	// Decision records the bump level when falling back to incrementing the patch component - if set
+	Decision *strategy.Decision
 }
 
-func (s Strategy) ReadVersion() (*semver.Version, error) {
+func (s Strategy) ReadVersion(ctx context.Context) (*semver.Version, error) {
 	log.Logger().Debug("Trying to read the previous version from the git tags first...")
-	v, err := s.FromTagStrategy.ReadVersion()
+	v, err := s.FromTagStrategy.ReadVersion(ctx)
 	if err == nil {
 		return v, nil
 	}
 
-	if err == fromtag.ErrNoTags || err == fromtag.ErrNoSemverTags {
+	if errors.Is(err, fromtag.ErrNoTags) || errors.Is(err, fromtag.ErrNoSemverTags) {
 		log.Logger().Debugf("Using fake version 0.0.0 because %s", err)
 		return semver.MustParse("0.0.0"), nil
 	}
@@ -29,9 +34,9 @@ func (s Strategy) ReadVersion() (*semver.Version, error) {
 	return nil, fmt.Errorf("failed to read previous version from tags: %w", err)
 }
 
-func (s Strategy) BumpVersion(previous semver.Version) (*semver.Version, error) {
+func (s Strategy) BumpVersion(ctx context.Context, previous semver.Version) (*semver.Version, error) {
 	log.Logger().Debug("Trying to bump the version using semantic release first...")
-	v, err := s.SemanticStrategy.BumpVersion(previous)
+	v, err := s.SemanticStrategy.BumpVersion(ctx, previous)
 	if err == nil {
 		return v, nil
 	}
@@ -39,6 +44,7 @@ func (s Strategy) BumpVersion(previous semver.Version) (*semver.Version, error)
 	if err == semantic.ErrPreviousVersionTagNotFound {
 		log.Logger().Debugf("The git repository has no tag for the previous version %s - fallback to incrementing the patch component of the previous version", previous.String())
 		next := previous.IncPatch()
+		s.Decision.SetBump("patch", "The git repository has no tag for the previous version")
 		return &next, nil
 	}
 
@@ -1,6 +1,7 @@
 package auto
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -57,7 +58,7 @@ func TestReadVersion(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
-			actual, err := test.strategy.ReadVersion()
+			actual, err := test.strategy.ReadVersion(context.Background())
 			if test.expectedErrorMsg != "" {
 				require.EqualError(t, err, test.expectedErrorMsg)
 				assert.Nil(t, actual)
@@ -119,7 +120,7 @@ func TestBumpVersion(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			// t.Parallel()
 
-			actual, err := test.strategy.BumpVersion(test.previous)
+			actual, err := test.strategy.BumpVersion(context.Background(), test.previous)
 			if test.expectedErrorMsg != "" {
 				require.EqualError(t, err, test.expectedErrorMsg)
 				assert.Nil(t, actual)
@@ -0,0 +1,160 @@
+package calver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// DefaultFormat is the calendar versioning format used if none is set
+const DefaultFormat = "YYYY.MM.MICRO"
+
+var (
+	// ErrInvalidFormat is returned for a format that can't be used to calculate a version
+	ErrInvalidFormat = errors.New("invalid calendar versioning format")
+	// ErrVersionExists is returned when the format has no micro counter, and the previous version already uses the current date
+	ErrVersionExists = errors.New("the version of the current date already exists")
+	// ErrPreviousInFuture is returned when the date of the previous version is after the current date
+	ErrPreviousInFuture = errors.New("the previous version is after the current date")
+)
+
+// microToken is the token of the counter of the versions released with the same date
+const microToken = "MICRO"
+
+// Strategy calculates the next version from the current date, using a calendar versioning format - see https://calver.org/
+// Each segment of the format - separated by dots - is one of the following tokens:
+//   - YYYY: the full year - 2026
+//   - YY and 0Y: the short year - 26
+//   - MM and 0M: the month - 1 or 01
+//   - WW and 0W: the ISO week - 1 or 01
+//   - DD and 0D: the day - 1 or 01
+//   - MICRO: the counter, which resets when the date changes, and increments otherwise. Only as the last segment.
+//
+// With a week token, the years are the ISO years of the week.
+type Strategy struct {
+	// Format is the calendar versioning format, such as YYYY.MM.MICRO - default to DefaultFormat
+	Format string
+	// Now returns the current date - default to time.Now
+	Now func() time.Time
+	// Decision records the bump - if set
+	Decision *strategy.Decision
+}
+
+func (s Strategy) BumpVersion(_ context.Context, previous semver.Version) (*semver.Version, error) {
+	format := s.Format
+	if format == "" {
+		format = DefaultFormat
+	}
+	tokens, err := parseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	date := now()
+	isoYear := strings.Contains(format, "W")
+
+	previousSegments := []uint64{previous.Major(), previous.Minor(), previous.Patch()}
+	segments := make([]string, len(tokens))
+	sameDate, afterDate := true, false
+	for i, token := range tokens {
+		if token == microToken {
+			continue
+		}
+		value := dateValue(token, date, isoYear)
+		segments[i] = formatValue(token, value)
+		if sameDate && uint64(value) != previousSegments[i] {
+			sameDate = false
+			afterDate = previousSegments[i] > uint64(value)
+		}
+	}
+
+	last := len(tokens) - 1
+	switch {
+	case afterDate:
+		return nil, fmt.Errorf("%w: previous version %s, with format %s", ErrPreviousInFuture, previous.Original(), format)
+	case tokens[last] != microToken && sameDate:
+		return nil, fmt.Errorf("%w: previous version %s, with format %s", ErrVersionExists, previous.Original(), format)
+	case tokens[last] != microToken:
+		s.Decision.SetBump("calver", fmt.Sprintf("Using the current date with the %s format", format))
+	case sameDate:
+		segments[last] = strconv.FormatUint(previousSegments[last]+1, 10)
+		log.Logger().Debugf("Previous version %s has the current date - incrementing the micro counter", previous.Original())
+		s.Decision.SetBump("calver", fmt.Sprintf("Same date as the previous version with the %s format - incrementing the micro counter", format))
+	default:
+		segments[last] = "0"
+		log.Logger().Debugf("Previous version %s has another date - resetting the micro counter", previous.Original())
+		s.Decision.SetBump("calver", fmt.Sprintf("New date with the %s format - resetting the micro counter", format))
+	}
+
+	// parsing the version keeps the zero-padded segments in its original form
+	next, err := semver.NewVersion(strings.Join(segments, "."))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the calendar version %s: %w", strings.Join(segments, "."), err)
+	}
+	return next, nil
+}
+
+// parseFormat returns the tokens of the format, one for each segment
+func parseFormat(format string) ([]string, error) {
+	tokens := strings.Split(format, ".")
+	if len(tokens) > 3 {
+		return nil, fmt.Errorf("%w %q: at most 3 segments are supported", ErrInvalidFormat, format)
+	}
+
+	hasDate := false
+	for i, token := range tokens {
+		switch token {
+		case "YYYY", "YY", "0Y", "MM", "0M", "WW", "0W", "DD", "0D":
+			hasDate = true
+		case microToken:
+			if i != len(tokens)-1 {
+				return nil, fmt.Errorf("%w %q: %s must be the last segment", ErrInvalidFormat, format, microToken)
+			}
+		default:
+			return nil, fmt.Errorf("%w %q: unknown token %q", ErrInvalidFormat, format, token)
+		}
+	}
+	if !hasDate {
+		return nil, fmt.Errorf("%w %q: at least one date segment is required", ErrInvalidFormat, format)
+	}
+	return tokens, nil
+}
+
+// dateValue returns the value of a date token for the given date - with the ISO year of the week if isoYear is set
+func dateValue(token string, date time.Time, isoYear bool) int {
+	year, week := date.ISOWeek()
+	if !isoYear {
+		year = date.Year()
+	}
+	switch token {
+	case "YYYY":
+		return year
+	case "YY", "0Y":
+		return year % 100
+	case "MM", "0M":
+		return int(date.Month())
+	case "WW", "0W":
+		return week
+	default:
+		return date.Day()
+	}
+}
+
+// formatValue formats the value of a date token - zero-padded for the 0-prefixed tokens
+func formatValue(token string, value int) string {
+	if strings.HasPrefix(token, "0") {
+		return fmt.Sprintf("%02d", value)
+	}
+	return strconv.Itoa(value)
+}
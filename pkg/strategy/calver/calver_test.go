@@ -0,0 +1,151 @@
+package calver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBumpVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		format           string
+		now              time.Time
+		previous         string
+		expected         string
+		expectedReason   string
+		expectedErrorMsg string
+	}{
+		{
+			name:           "default format with a new date",
+			now:            time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			previous:       "2026.9.4",
+			expected:       "2026.10.0",
+			expectedReason: "New date with the YYYY.MM.MICRO format - resetting the micro counter",
+		},
+		{
+			name:           "default format with the same date",
+			now:            time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			previous:       "2026.10.2",
+			expected:       "2026.10.3",
+			expectedReason: "Same date as the previous version with the YYYY.MM.MICRO format - incrementing the micro counter",
+		},
+		{
+			name:     "no previous version",
+			format:   "YYYY.MM.MICRO",
+			now:      time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			previous: "0.0.0",
+			expected: "2026.10.0",
+		},
+		{
+			name:     "zero-padded month with the same date",
+			format:   "YY.0M.MICRO",
+			now:      time.Date(2026, time.May, 2, 12, 0, 0, 0, time.UTC),
+			previous: "v26.05.3",
+			expected: "26.05.4",
+		},
+		{
+			name:     "zero-padded month with a new date",
+			format:   "YY.0M.MICRO",
+			now:      time.Date(2027, time.January, 2, 12, 0, 0, 0, time.UTC),
+			previous: "26.12.3",
+			expected: "27.01.0",
+		},
+		{
+			name:           "week",
+			format:         "YYYY.WW",
+			now:            time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			previous:       "2026.41",
+			expected:       "2026.42",
+			expectedReason: "Using the current date with the YYYY.WW format",
+		},
+		{
+			name:     "week with the ISO year",
+			format:   "YYYY.0W.MICRO",
+			now:      time.Date(2026, time.December, 29, 12, 0, 0, 0, time.UTC),
+			previous: "2026.52.1",
+			expected: "2026.53.0",
+		},
+		{
+			name:     "day",
+			format:   "0Y.0M.0D",
+			now:      time.Date(2026, time.March, 7, 12, 0, 0, 0, time.UTC),
+			previous: "26.03.06",
+			expected: "26.03.07",
+		},
+		{
+			name:             "same date without micro counter",
+			format:           "YYYY.WW",
+			now:              time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			previous:         "2026.42",
+			expectedErrorMsg: "the version of the current date already exists: previous version 2026.42, with format YYYY.WW",
+		},
+		{
+			name:             "previous version in the future",
+			now:              time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			previous:         "2026.11.0",
+			expectedErrorMsg: "the previous version is after the current date: previous version 2026.11.0, with format YYYY.MM.MICRO",
+		},
+		{
+			name:             "unknown token",
+			format:           "YYYY.MONTH.MICRO",
+			now:              time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			previous:         "0.0.0",
+			expectedErrorMsg: `invalid calendar versioning format "YYYY.MONTH.MICRO": unknown token "MONTH"`,
+		},
+		{
+			name:             "micro not last",
+			format:           "YYYY.MICRO.MM",
+			now:              time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			previous:         "0.0.0",
+			expectedErrorMsg: `invalid calendar versioning format "YYYY.MICRO.MM": MICRO must be the last segment`,
+		},
+		{
+			name:             "no date",
+			format:           "MICRO",
+			now:              time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			previous:         "0.0.0",
+			expectedErrorMsg: `invalid calendar versioning format "MICRO": at least one date segment is required`,
+		},
+		{
+			name:             "too many segments",
+			format:           "YYYY.MM.DD.MICRO",
+			now:              time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+			previous:         "0.0.0",
+			expectedErrorMsg: `invalid calendar versioning format "YYYY.MM.DD.MICRO": at most 3 segments are supported`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			decision := &strategy.Decision{}
+			s := Strategy{
+				Format:   test.format,
+				Now:      func() time.Time { return test.now },
+				Decision: decision,
+			}
+			actual, err := s.BumpVersion(context.Background(), *semver.MustParse(test.previous))
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual.Original())
+			assert.Equal(t, "calver", decision.Bump)
+			if test.expectedReason != "" {
+				assert.Equal(t, test.expectedReason, decision.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package describe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// DefaultChannel is the pre-release identifier of the development versions if none is set
+const DefaultChannel = "dev"
+
+// abbreviatedHashLength is the length of the abbreviated hash of the HEAD commit, like `git describe`
+const abbreviatedHashLength = 7
+
+// Strategy bumps the version to a development version of the next release, like `git describe` or setuptools-scm:
+// `<target>-<channel>.<distance>+g<hash>`, such as `1.4.0-dev.7+g3f2a1bc` for the 7th commit since `v1.3.0`.
+// The target is calculated by the wrapped Bumper - or is the next patch if no release is needed -
+// the distance is the number of commits since the previous version, and the hash is the abbreviated hash of HEAD.
+// The `dirty` build metadata is added if the worktree has uncommitted changes, such as `1.4.0-dev.7+g3f2a1bc.dirty`.
+type Strategy struct {
+	Dir string
+	// Channel is the pre-release identifier - default to DefaultChannel
+	Channel string
+	// Bumper calculates the target from the previous version
+	Bumper strategy.VersionBumper
+	// SemanticStrategy finds the commits since the previous version, to count them
+	SemanticStrategy semantic.Strategy
+	// Decision records the bump level - if set
+	Decision *strategy.Decision
+}
+
+func (s Strategy) BumpVersion(ctx context.Context, previous semver.Version) (*semver.Version, error) {
+	channel := s.Channel
+	if channel == "" {
+		channel = DefaultChannel
+	}
+
+	target, err := s.target(ctx, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	distance, err := s.distance(ctx, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := s.Dir
+	if dir == "" {
+		dir, err = os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current working directory: %w", err)
+		}
+	}
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository at %q: %w", dir, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the HEAD reference: %w", err)
+	}
+	metadata := "g" + head.Hash().String()[:abbreviatedHashLength]
+
+	dirty, err := isDirty(repo)
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		log.Logger().Debugf("The worktree of the git repository at %q has uncommitted changes", dir)
+		metadata += ".dirty"
+	}
+
+	version, err := target.SetPrerelease(fmt.Sprintf("%s.%d", channel, distance))
+	if err != nil {
+		return nil, fmt.Errorf("invalid development version channel %q: %w", channel, err)
+	}
+	version, err = version.SetMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Logger().Debugf("Development version of %s is %s", target.String(), version.String())
+	s.Decision.SetBump("describe", s.reason(fmt.Sprintf("Development version of %s, %d commits since version %s", target.String(), distance, previous.String())))
+	return &version, nil
+}
+
+// reason returns the reason of the bump, including the reason of the wrapped bumper
+func (s Strategy) reason(reason string) string {
+	if previous := s.Decision.PreviousReason(); previous != "" {
+		return previous + " - " + reason
+	}
+	return reason
+}
+
+// target returns the version of the next release, without pre-release or metadata
+func (s Strategy) target(ctx context.Context, previous semver.Version) (*semver.Version, error) {
+	if s.Bumper == nil {
+		return nil, fmt.Errorf("no strategy to calculate the next release after version %s", previous.String())
+	}
+	next, err := s.Bumper.BumpVersion(ctx, previous)
+	if errors.Is(err, semantic.ErrNoRelease) {
+		log.Logger().Debugf("No release needed since version %s - using the next patch as the target of the development version", previous.String())
+		patch := previous.IncPatch()
+		next, err = &patch, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return semver.New(next.Major(), next.Minor(), next.Patch(), "", ""), nil
+}
+
+// distance returns the number of commits since the previous version - or since the beginning if it has no tag
+func (s Strategy) distance(ctx context.Context, previous semver.Version) (int, error) {
+	commits, err := s.SemanticStrategy.Commits(ctx, &previous)
+	if errors.Is(err, semantic.ErrPreviousVersionTagNotFound) {
+		log.Logger().Debugf("The git repository has no tag for the previous version %s - counting all the commits", previous.String())
+		commits, err = s.SemanticStrategy.Commits(ctx, nil)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list the commits since version %s: %w", previous.String(), err)
+	}
+	return len(commits), nil
+}
+
+// isDirty returns true if the worktree of the repository has uncommitted changes - ignoring the untracked files
+func isDirty(repo *git.Repository) (bool, error) {
+	worktree, err := repo.Worktree()
+	if errors.Is(err, git.ErrIsBareRepository) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get the worktree of the git repository: %w", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return false, fmt.Errorf("failed to get the status of the worktree: %w", err)
+	}
+	for _, fileStatus := range status {
+		if fileStatus.Staging != git.Untracked || fileStatus.Worktree != git.Untracked {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,166 @@
+package describe
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/auto"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBumpVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		commits          []string
+		tags             map[string]int
+		channel          string
+		dirty            bool
+		untracked        bool
+		previous         semver.Version
+		expected         string
+		expectedReason   string
+		expectedErrorMsg string
+	}{
+		{
+			name:           "commits since the previous version",
+			commits:        []string{"chore: init", "feat: one", "fix: two", "fix: three"},
+			tags:           map[string]int{"v1.3.0": 0},
+			previous:       *semver.MustParse("1.3.0"),
+			expected:       "1.4.0-dev.3+g%s",
+			expectedReason: `Found at least 1 "feat" commit - Development version of 1.4.0, 3 commits since version 1.3.0`,
+		},
+		{
+			name:     "custom channel",
+			commits:  []string{"chore: init", "fix: one"},
+			tags:     map[string]int{"v1.3.0": 0},
+			channel:  "snapshot",
+			previous: *semver.MustParse("1.3.0"),
+			expected: "1.3.1-snapshot.1+g%s",
+		},
+		{
+			name:     "dirty worktree",
+			commits:  []string{"chore: init", "feat!: breaking"},
+			tags:     map[string]int{"v1.3.0": 0},
+			dirty:    true,
+			previous: *semver.MustParse("1.3.0"),
+			expected: "2.0.0-dev.1+g%s.dirty",
+		},
+		{
+			name:      "untracked files",
+			commits:   []string{"chore: init", "fix: one"},
+			tags:      map[string]int{"v1.3.0": 0},
+			untracked: true,
+			previous:  *semver.MustParse("1.3.0"),
+			expected:  "1.3.1-dev.1+g%s",
+		},
+		{
+			name:     "no release needed",
+			commits:  []string{"chore: init", "docs: one", "docs: two"},
+			tags:     map[string]int{"v1.3.0": 0},
+			previous: *semver.MustParse("1.3.0"),
+			expected: "1.3.1-dev.2+g%s",
+		},
+		{
+			name:     "no tag",
+			commits:  []string{"chore: init", "feat: one"},
+			previous: *semver.MustParse("0.0.0"),
+			expected: "0.0.1-dev.2+g%s",
+		},
+		{
+			name:             "invalid channel",
+			commits:          []string{"chore: init", "fix: one"},
+			tags:             map[string]int{"v1.3.0": 0},
+			channel:          "dev_1",
+			previous:         *semver.MustParse("1.3.0"),
+			expectedErrorMsg: `invalid development version channel "dev_1": invalid prerelease string`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			head := initRepository(t, dir, test.commits, test.tags)
+			if test.dirty {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), []byte("changed"), 0o600))
+			}
+			if test.untracked {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "untracked"), []byte("untracked"), 0o600))
+			}
+
+			decision := &strategy.Decision{}
+			semanticStrategy := semantic.Strategy{
+				Dir:       dir,
+				TagPrefix: "v",
+				Decision:  decision,
+			}
+			s := Strategy{
+				Dir:              dir,
+				Channel:          test.channel,
+				Bumper:           auto.Strategy{SemanticStrategy: semanticStrategy, Decision: decision},
+				SemanticStrategy: semanticStrategy,
+				Decision:         decision,
+			}
+			actual, err := s.BumpVersion(context.Background(), test.previous)
+			if test.expectedErrorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErrorMsg)
+				assert.Nil(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, semver.MustParse(replaceHash(test.expected, head)), actual)
+			assert.Equal(t, "describe", decision.Bump)
+			if test.expectedReason != "" {
+				assert.Equal(t, test.expectedReason, decision.Reason)
+			}
+		})
+	}
+}
+
+// initRepository creates a git repository with a commit for each message, and the tags on the commits at the given indexes.
+// It returns the hash of the last commit.
+func initRepository(t *testing.T, dir string, messages []string, tags map[string]int) plumbing.Hash {
+	t.Helper()
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	var hashes []plumbing.Hash
+	for i, message := range messages {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), []byte(message), 0o600))
+		_, err = w.Add("file")
+		require.NoError(t, err)
+		signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Date(2021, 1, 1, i, 0, 0, 0, time.UTC)}
+		hash, err := w.Commit(message, &git.CommitOptions{Author: signature, Committer: signature})
+		require.NoError(t, err)
+		hashes = append(hashes, hash)
+	}
+	for tagName, index := range tags {
+		_, err = repo.CreateTag(tagName, hashes[index], nil)
+		require.NoError(t, err)
+	}
+	return hashes[len(hashes)-1]
+}
+
+// replaceHash replaces the %s placeholder of the expected version with the abbreviated hash
+func replaceHash(expected string, hash plumbing.Hash) string {
+	return fmt.Sprintf(expected, hash.String()[:abbreviatedHashLength])
+}
@@ -48,3 +48,9 @@ func (r AutomakeVersionReader) ReadFileVersion(filePath string) (string, error)
 
 	return "", ErrFileHasNoVersion
 }
+
+func (r AutomakeVersionReader) WriteFileVersion(filePath, version string) error {
+	return rewriteFile(filePath, func(content []byte) ([]byte, error) {
+		return replaceSubmatch(content, configureRegexp, 2, version)
+	})
+}
@@ -0,0 +1,139 @@
+package fromfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+var (
+	// cargoInheritedVersionRegexp matches `version = { workspace = true }`
+	cargoInheritedVersionRegexp = regexp.MustCompile(`^\{\s*workspace\s*=\s*true\s*\}$`)
+)
+
+// CargoVersionReader reads the version of a Rust crate or workspace.
+// If the crate inherits its version from the workspace (`version.workspace = true`),
+// the version is read from - and written to - the workspace root manifest.
+type CargoVersionReader struct {
+}
+
+func (r CargoVersionReader) String() string {
+	return "cargo"
+}
+
+func (r CargoVersionReader) SupportedFiles() []string {
+	return []string{
+		"Cargo.toml",
+	}
+}
+
+func (r CargoVersionReader) ReadFileVersion(filePath string) (string, error) {
+	_, entry, err := r.findVersionEntry(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	version, _, _, _ := entry.stringValue()
+	return version, nil
+}
+
+func (r CargoVersionReader) WriteFileVersion(filePath, version string) error {
+	manifestPath, entry, err := r.findVersionEntry(filePath)
+	if err != nil {
+		return err
+	}
+
+	_, start, end, _ := entry.stringValue()
+	return rewriteFile(manifestPath, func(content []byte) ([]byte, error) {
+		return spliceBytes(content, start, end, version), nil
+	})
+}
+
+// findVersionEntry returns the path of the manifest which holds the version of the given manifest,
+// and the entry for this version.
+func (r CargoVersionReader) findVersionEntry(filePath string) (string, tomlEntry, error) {
+	content, err := os.ReadFile(filePath) // #nosec G304 -- user-provided version file path
+	if err != nil {
+		return "", tomlEntry{}, err
+	}
+	entries := parseTOMLEntries(content)
+	if !r.inheritsVersion(entries) {
+		entry, found := r.ownVersionEntry(entries)
+		if !found {
+			return "", tomlEntry{}, ErrFileHasNoVersion
+		}
+		return filePath, entry, nil
+	}
+
+	if entry, found := stringTOMLEntry(entries, "workspace.package", "version"); found {
+		return filePath, entry, nil
+	}
+
+	rootPath, err := r.findWorkspaceRoot(filepath.Dir(filePath))
+	if err != nil {
+		return "", tomlEntry{}, err
+	}
+	log.Logger().Debugf("Crate %s inherits its version from the workspace %s", filePath, rootPath)
+
+	rootContent, err := os.ReadFile(rootPath) // #nosec G304 -- workspace manifest found from the user-provided version file path
+	if err != nil {
+		return "", tomlEntry{}, err
+	}
+	entry, found := stringTOMLEntry(parseTOMLEntries(rootContent), "workspace.package", "version")
+	if !found {
+		return "", tomlEntry{}, fmt.Errorf("workspace %s has no [workspace.package] version: %w", rootPath, ErrFileHasNoVersion)
+	}
+	return rootPath, entry, nil
+}
+
+// ownVersionEntry returns the version declared in the manifest itself:
+// either the version of the package, or the version shared by the workspace members.
+func (r CargoVersionReader) ownVersionEntry(entries []tomlEntry) (tomlEntry, bool) {
+	if entry, found := stringTOMLEntry(entries, "package", "version"); found {
+		return entry, true
+	}
+	return stringTOMLEntry(entries, "workspace.package", "version")
+}
+
+// inheritsVersion returns true if the package declares `version.workspace = true`
+// or `version = { workspace = true }`
+func (r CargoVersionReader) inheritsVersion(entries []tomlEntry) bool {
+	if entry, found := lookupTOMLEntry(entries, "package", "version.workspace"); found {
+		return entry.value == "true"
+	}
+	if entry, found := lookupTOMLEntry(entries, "package", "version"); found {
+		return cargoInheritedVersionRegexp.MatchString(entry.value)
+	}
+	return false
+}
+
+// findWorkspaceRoot walks up the parent directories to find the manifest with a [workspace] table
+func (r CargoVersionReader) findWorkspaceRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("could not find the workspace root manifest: %w", ErrFileHasNoVersion)
+		}
+		dir = parent
+
+		manifestPath := filepath.Join(dir, "Cargo.toml")
+		content, err := os.ReadFile(manifestPath) // #nosec G304 -- workspace manifest found from the user-provided version file path
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if hasTOMLTable(content, "workspace") {
+			return manifestPath, nil
+		}
+	}
+}
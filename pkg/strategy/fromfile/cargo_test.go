@@ -0,0 +1,63 @@
+package fromfile
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCargoVersionReader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		filePath         string
+		expected         string
+		expectedErrorMsg string
+	}{
+		{
+			name:     "package",
+			filePath: "cargo/Cargo.toml",
+			expected: "1.2.15",
+		},
+		{
+			name:     "workspace root",
+			filePath: "cargo-workspace/Cargo.toml",
+			expected: "1.2.16",
+		},
+		{
+			name:     "workspace member",
+			filePath: "cargo-workspace/crates/member/Cargo.toml",
+			expected: "1.2.16",
+		},
+		{
+			name:             "file does not exists",
+			filePath:         "does-not-exists.toml",
+			expectedErrorMsg: "open testdata/does-not-exists.toml: no such file or directory",
+		},
+		{
+			name:             "invalid file",
+			filePath:         "Chart.yaml",
+			expectedErrorMsg: "the file has no version",
+		},
+	}
+
+	reader := CargoVersionReader{}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := reader.ReadFileVersion(filepath.Join("testdata", test.filePath))
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Empty(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
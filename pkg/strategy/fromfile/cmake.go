@@ -2,9 +2,12 @@ package fromfile
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"regexp"
 	"strings"
+
+	"github.com/Masterminds/semver/v3"
 )
 
 var (
@@ -47,3 +50,18 @@ func (r CMakeVersionReader) ReadFileVersion(filePath string) (string, error) {
 
 	return "", ErrFileHasNoVersion
 }
+
+// WriteFileVersion writes the version in the project command, which only accepts numeric components:
+// pre-release versions and build metadata are refused.
+func (r CMakeVersionReader) WriteFileVersion(filePath, version string) error {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return err
+	}
+	if v.Prerelease() != "" || v.Metadata() != "" {
+		return fmt.Errorf("the CMake project version only has numeric components, it can't be %s", version)
+	}
+	return rewriteFile(filePath, func(content []byte) ([]byte, error) {
+		return replaceSubmatch(content, cmakeRegexp, 3, version)
+	})
+}
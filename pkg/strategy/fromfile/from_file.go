@@ -1,10 +1,12 @@
 package fromfile
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -20,29 +22,8 @@ type Strategy struct {
 	FilePath string
 }
 
-func (s Strategy) ReadVersion() (*semver.Version, error) {
-	var (
-		dir = s.Dir
-		err error
-	)
-	if dir == "" {
-		dir, err = os.Getwd()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get current working directory: %w", err)
-		}
-	}
-
-	var (
-		reader    FileVersionReader
-		filePaths []string
-	)
-	if s.FilePath != "" {
-		filePath := filepath.Join(dir, s.FilePath)
-		filePaths = append(filePaths, filePath)
-		reader, err = s.getReader()
-	} else {
-		reader, filePaths, err = s.autoDetect(dir)
-	}
+func (s Strategy) ReadVersion(_ context.Context) (*semver.Version, error) {
+	reader, filePaths, err := s.candidateFiles()
 	if err != nil {
 		return nil, err
 	}
@@ -71,8 +52,67 @@ func (s Strategy) ReadVersion() (*semver.Version, error) {
 	return semver.NewVersion(version)
 }
 
-func (s Strategy) BumpVersion(_ semver.Version) (*semver.Version, error) {
-	return s.ReadVersion()
+func (s Strategy) BumpVersion(ctx context.Context, _ semver.Version) (*semver.Version, error) {
+	return s.ReadVersion(ctx)
+}
+
+// WriteVersion writes the given version in place of the current version,
+// in every candidate file which already has a version.
+func (s Strategy) WriteVersion(version string) error {
+	reader, filePaths, err := s.candidateFiles()
+	if err != nil {
+		return err
+	}
+
+	writer, ok := reader.(FileVersionWriter)
+	if !ok {
+		return fmt.Errorf("the %s reader does not support writing the version", reader)
+	}
+
+	var written int
+	for _, filePath := range filePaths {
+		log.Logger().Debugf("Writing version %s to file %s using writer %s", version, filePath, reader)
+		err = writer.WriteFileVersion(filePath, version)
+		if errors.Is(err, ErrFileHasNoVersion) {
+			log.Logger().Debugf("File %s has no version", filePath)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write version %s to file %s: %w", version, filePath, err)
+		}
+		written++
+	}
+
+	if written == 0 {
+		return fmt.Errorf("could not write version to %s using writer %s", filePaths, reader)
+	}
+
+	return nil
+}
+
+// candidateFiles returns the reader to use, and the files it should read,
+// either from the configured file path or by auto-detection.
+func (s Strategy) candidateFiles() (FileVersionReader, []string, error) {
+	var (
+		dir = s.Dir
+		err error
+	)
+	if dir == "" {
+		dir, err = os.Getwd()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get current working directory: %w", err)
+		}
+	}
+
+	if s.FilePath != "" {
+		reader, err := s.getReader()
+		if err != nil {
+			return nil, nil, err
+		}
+		return reader, []string{filepath.Join(dir, s.FilePath)}, nil
+	}
+
+	return s.autoDetect(dir)
 }
 
 func (s Strategy) autoDetect(dir string) (FileVersionReader, []string, error) {
@@ -111,6 +151,12 @@ type FileVersionReader interface {
 	String() string
 }
 
+// FileVersionWriter replaces the version of a file, leaving the rest
+// of its content - formatting, comments, key ordering - untouched.
+type FileVersionWriter interface {
+	WriteFileVersion(filePath, version string) error
+}
+
 // fileVersionReaders is an ordered list of all readers to try
 // when auto-detecting the file to use
 var fileVersionReaders = []FileVersionReader{
@@ -122,4 +168,44 @@ var fileVersionReaders = []FileVersionReader{
 	MavenPOMVersionReader{},
 	JsPackageVersionReader{},
 	GradleVersionReader{},
+	CargoVersionReader{},
+}
+
+// rewriteFile applies the rewrite func to the content of the given file,
+// and writes the result back with the same permissions.
+func rewriteFile(filePath string, rewrite func(content []byte) ([]byte, error)) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(filePath) // #nosec G304 -- user-provided version file path
+	if err != nil {
+		return err
+	}
+
+	updated, err := rewrite(content)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, updated, info.Mode().Perm())
+}
+
+// replaceSubmatch replaces the given submatch of the first match of re in content.
+func replaceSubmatch(content []byte, re *regexp.Regexp, group int, replacement string) ([]byte, error) {
+	loc := re.FindSubmatchIndex(content)
+	if len(loc) <= 2*group+1 || loc[2*group] < 0 {
+		return nil, ErrFileHasNoVersion
+	}
+	return spliceBytes(content, loc[2*group], loc[2*group+1], replacement), nil
+}
+
+// spliceBytes returns a copy of content where the bytes between start and end are replaced.
+func spliceBytes(content []byte, start, end int, replacement string) []byte {
+	updated := make([]byte, 0, len(content)-(end-start)+len(replacement))
+	updated = append(updated, content[:start]...)
+	updated = append(updated, replacement...)
+	updated = append(updated, content[end:]...)
+	return updated
 }
@@ -1,7 +1,10 @@
 package fromfile
 
 import (
+	"context"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Masterminds/semver/v3"
@@ -56,6 +59,14 @@ func TestReadVersion(t *testing.T) {
 			},
 			expected: semver.MustParse("1.2.4"),
 		},
+		{
+			name: "Makefile with other VERSION variables",
+			strategy: Strategy{
+				Dir:      filepath.Join("testdata", "makefile"),
+				FilePath: "Makefile",
+			},
+			expected: semver.MustParse("1.2.21"),
+		},
 		{
 			name: "Automake",
 			strategy: Strategy{
@@ -120,6 +131,44 @@ func TestReadVersion(t *testing.T) {
 			},
 			expected: semver.MustParse("1.2.8"),
 		},
+		{
+			name: "Cargo",
+			strategy: Strategy{
+				Dir:      filepath.Join("testdata", "cargo"),
+				FilePath: "Cargo.toml",
+			},
+			expected: semver.MustParse("1.2.15"),
+		},
+		{
+			name: "Cargo workspace member",
+			strategy: Strategy{
+				Dir:      filepath.Join("testdata", "cargo-workspace"),
+				FilePath: filepath.Join("crates", "member", "Cargo.toml"),
+			},
+			expected: semver.MustParse("1.2.16"),
+		},
+		{
+			name: "auto detect cargo",
+			strategy: Strategy{
+				Dir: filepath.Join("testdata", "cargo-workspace", "crates", "member"),
+			},
+			expected: semver.MustParse("1.2.16"),
+		},
+		{
+			name: "auto detect python",
+			strategy: Strategy{
+				Dir: filepath.Join("testdata", "python"),
+			},
+			expected: semver.MustParse("1.3.0-rc.1"),
+		},
+		{
+			name: "Python module",
+			strategy: Strategy{
+				Dir:      filepath.Join("testdata", "python"),
+				FilePath: filepath.Join("my_package", "__init__.py"),
+			},
+			expected: semver.MustParse("1.2.19-dev.2"),
+		},
 		{
 			name: "unknown file",
 			strategy: Strategy{
@@ -135,7 +184,7 @@ func TestReadVersion(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
-			actual, err := test.strategy.ReadVersion()
+			actual, err := test.strategy.ReadVersion(context.Background())
 			if test.expectedErrorMsg != "" {
 				require.EqualError(t, err, test.expectedErrorMsg)
 				assert.Nil(t, actual)
@@ -203,3 +252,191 @@ func TestAutoDetect(t *testing.T) {
 	}
 
 }
+
+func TestWriteVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		dir              string
+		files            []string
+		filePath         string
+		previous         string
+		version          string
+		expected         string
+		expectedErrorMsg string
+	}{
+		{
+			name:     "Helm Chart",
+			files:    []string{"Chart.yaml"},
+			filePath: "Chart.yaml",
+			previous: "1.2.3",
+		},
+		{
+			name:     "Makefile",
+			files:    []string{"Makefile"},
+			filePath: "Makefile",
+			previous: "1.2.4",
+		},
+		{
+			name:     "Makefile with other VERSION variables",
+			dir:      "makefile",
+			files:    []string{"Makefile"},
+			filePath: "Makefile",
+			previous: "1.2.21",
+		},
+		{
+			name:     "Automake",
+			files:    []string{"configure.ac"},
+			filePath: "configure.ac",
+			previous: "1.2.5",
+		},
+		{
+			name:     "CMake",
+			files:    []string{"CMakeLists.txt"},
+			filePath: "CMakeLists.txt",
+			previous: "1.2.6",
+		},
+		{
+			name:             "CMake pre-release",
+			files:            []string{"CMakeLists.txt"},
+			filePath:         "CMakeLists.txt",
+			version:          "2.3.4-rc.1",
+			expectedErrorMsg: "failed to write version 2.3.4-rc.1 to file %s/CMakeLists.txt: the CMake project version only has numeric components, it can't be 2.3.4-rc.1",
+		},
+		{
+			name:     "Python",
+			files:    []string{"setup.py"},
+			filePath: "setup.py",
+			previous: "1.2.11",
+		},
+		{
+			name:     "Maven POM",
+			files:    []string{"pom.xml"},
+			filePath: "pom.xml",
+			previous: "1.2.9",
+		},
+		{
+			name:     "Javascript package.json",
+			files:    []string{"package.json"},
+			filePath: "package.json",
+			previous: "1.2.10",
+		},
+		{
+			name:     "Gradle (kotlin)",
+			files:    []string{"build.gradle.kts"},
+			filePath: "build.gradle.kts",
+			previous: "1.2.8",
+		},
+		{
+			name:     "auto detect gradle",
+			dir:      "gradle",
+			files:    []string{"build.gradle", "gradle.properties"},
+			previous: "1.2.7",
+		},
+		{
+			name:     "Gradle pre-release",
+			files:    []string{"build.gradle.kts"},
+			filePath: "build.gradle.kts",
+			previous: "1.2.8",
+			version:  "2.3.4-rc.1",
+		},
+		{
+			name:     "Cargo",
+			dir:      "cargo",
+			files:    []string{"Cargo.toml"},
+			filePath: "Cargo.toml",
+			previous: "1.2.15",
+		},
+		{
+			name:     "Cargo workspace member",
+			dir:      "cargo-workspace",
+			files:    []string{"Cargo.toml", filepath.Join("crates", "member", "Cargo.toml")},
+			filePath: filepath.Join("crates", "member", "Cargo.toml"),
+			previous: "1.2.16",
+		},
+		{
+			name:     "Python pyproject.toml",
+			dir:      "python",
+			files:    []string{"pyproject.toml"},
+			filePath: "pyproject.toml",
+			previous: "1.3.0rc1",
+			version:  "1.3.0-rc.2",
+			expected: "1.3.0rc2",
+		},
+		{
+			name:     "Python setup.cfg",
+			dir:      filepath.Join("python", "setup-cfg"),
+			files:    []string{"setup.cfg", "setup.py"},
+			previous: "1.2.18",
+		},
+		{
+			name:     "Python module",
+			dir:      filepath.Join("python", "my_package"),
+			files:    []string{"__init__.py"},
+			filePath: "__init__.py",
+			previous: "1.2.19.dev2",
+			version:  "1.2.19",
+		},
+		{
+			name:             "file without version",
+			dir:              "gradle",
+			files:            []string{"build.gradle"},
+			filePath:         "build.gradle",
+			expectedErrorMsg: "could not write version to [%s/build.gradle] using writer gradle",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			originals := map[string]string{}
+			for _, file := range test.files {
+				content, err := os.ReadFile(filepath.Join("testdata", test.dir, file))
+				require.NoError(t, err)
+				originals[file] = string(content)
+				err = os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0700)
+				require.NoError(t, err)
+				err = os.WriteFile(filepath.Join(dir, file), content, 0600)
+				require.NoError(t, err)
+			}
+
+			version, expected := test.version, test.expected
+			if version == "" {
+				version = "2.3.4"
+			}
+			if expected == "" {
+				expected = version
+			}
+
+			s := Strategy{
+				Dir:      dir,
+				FilePath: test.filePath,
+			}
+			err := s.WriteVersion(version)
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, strings.ReplaceAll(test.expectedErrorMsg, "%s", dir))
+				return
+			}
+			require.NoError(t, err)
+
+			for fileName, original := range originals {
+				actual, err := os.ReadFile(filepath.Join(dir, fileName))
+				require.NoError(t, err)
+				expectedContent := original
+				if strings.Contains(original, test.previous) {
+					expectedContent = strings.Replace(original, test.previous, expected, 1)
+				}
+				assert.Equal(t, expectedContent, string(actual), "unexpected content for %s", fileName)
+			}
+
+			// the written version can be read again
+			actual, err := s.ReadVersion(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, semver.MustParse(version).String(), actual.String())
+		})
+	}
+}
@@ -8,7 +8,9 @@ import (
 )
 
 var (
-	gradleRegexp = regexp.MustCompile(`^version\s*=\s*['"]([.\d]+(-\w+)?)['"]`)
+	gradleRegexp = regexp.MustCompile(`^version\s*=\s*['"]([^'"]+)['"]`)
+	// gradleWriteRegexp is the multi-line counterpart of gradleRegexp, used to write the version
+	gradleWriteRegexp = regexp.MustCompile(`(?m)^version\s*=\s*['"]([^'"]+)['"]`)
 )
 
 type GradleVersionReader struct {
@@ -50,3 +52,9 @@ func (r GradleVersionReader) ReadFileVersion(filePath string) (string, error) {
 
 	return "", ErrFileHasNoVersion
 }
+
+func (r GradleVersionReader) WriteFileVersion(filePath, version string) error {
+	return rewriteFile(filePath, func(content []byte) ([]byte, error) {
+		return replaceSubmatch(content, gradleWriteRegexp, 1, version)
+	})
+}
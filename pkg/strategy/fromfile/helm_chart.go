@@ -2,10 +2,16 @@ package fromfile
 
 import (
 	"os"
+	"regexp"
 
 	"gopkg.in/yaml.v3"
 )
 
+var (
+	// helmChartVersionRegexp matches the top-level `version` key, but not `appVersion` or nested keys
+	helmChartVersionRegexp = regexp.MustCompile(`(?m)^version:[ \t]*["']?([^"'\s#]+)["']?`)
+)
+
 type HelmChartVersionReader struct {
 }
 
@@ -39,6 +45,12 @@ func (r HelmChartVersionReader) ReadFileVersion(filePath string) (string, error)
 	return chart.Version, nil
 }
 
+func (r HelmChartVersionReader) WriteFileVersion(filePath, version string) error {
+	return rewriteFile(filePath, func(content []byte) ([]byte, error) {
+		return replaceSubmatch(content, helmChartVersionRegexp, 1, version)
+	})
+}
+
 type HelmChart struct {
 	Version string `yaml:"version"`
 }
@@ -1,6 +1,7 @@
 package fromfile
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 )
@@ -38,6 +39,65 @@ func (r JsPackageVersionReader) ReadFileVersion(filePath string) (string, error)
 	return pkg.Version, nil
 }
 
+func (r JsPackageVersionReader) WriteFileVersion(filePath, version string) error {
+	return rewriteFile(filePath, func(content []byte) ([]byte, error) {
+		start, end, err := findJSPackageVersion(content)
+		if err != nil {
+			return nil, err
+		}
+		return spliceBytes(content, start, end, version), nil
+	})
+}
+
+// findJSPackageVersion returns the offsets of the value of the top-level "version" key,
+// without the surrounding quotes.
+func findJSPackageVersion(content []byte) (start, end int, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	var (
+		depth     int
+		expectKey bool
+	)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, 0, ErrFileHasNoVersion
+		}
+
+		switch t := token.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				depth++
+				expectKey = true
+			case '[':
+				depth++
+				expectKey = false
+			case '}', ']':
+				depth--
+				expectKey = depth > 0
+			}
+			continue
+		case string:
+			if depth == 1 && expectKey && t == "version" {
+				value, err := decoder.Token()
+				if err != nil {
+					return 0, 0, err
+				}
+				if _, ok := value.(string); !ok {
+					return 0, 0, ErrFileHasNoVersion
+				}
+				end = int(decoder.InputOffset()) - 1
+				start = bytes.LastIndexByte(content[:end], '"') + 1
+				return start, end, nil
+			}
+		}
+
+		if depth == 1 {
+			expectKey = !expectKey
+		}
+	}
+}
+
 type JsPackage struct {
 	Version string `json:"version"`
 }
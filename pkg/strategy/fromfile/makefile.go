@@ -1,9 +1,13 @@
 package fromfile
 
 import (
-	"bufio"
 	"os"
-	"strings"
+	"regexp"
+)
+
+var (
+	// makefileVersionRegexp matches the assignment of the VERSION variable, such as `VERSION := 1.2.3`
+	makefileVersionRegexp = regexp.MustCompile(`(?m)^VERSION[ \t]*[:?+!]*=[ \t]*([^\s#]+)`)
 )
 
 type MakefileVersionReader struct {
@@ -20,26 +24,21 @@ func (r MakefileVersionReader) SupportedFiles() []string {
 }
 
 func (r MakefileVersionReader) ReadFileVersion(filePath string) (string, error) {
-	f, err := os.Open(filePath) // #nosec G304 -- user-provided version file path
+	content, err := os.ReadFile(filePath) // #nosec G304 -- user-provided version file path
 	if err != nil {
 		return "", err
 	}
-	defer func() { _ = f.Close() }()
-
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		if strings.HasPrefix(scanner.Text(), "VERSION") || strings.HasPrefix(scanner.Text(), "VERSION ") || strings.HasPrefix(scanner.Text(), "VERSION:") || strings.HasPrefix(scanner.Text(), "VERSION=") {
-			parts := strings.Split(scanner.Text(), "=")
-			if len(parts) < 2 {
-				continue
-			}
-
-			v := strings.TrimSpace(parts[1])
-			if v != "" {
-				return v, nil
-			}
-		}
+
+	// the same regexp as the writer, so that the version read is the one written
+	matched := makefileVersionRegexp.FindSubmatch(content)
+	if len(matched) < 2 {
+		return "", ErrFileHasNoVersion
 	}
+	return string(matched[1]), nil
+}
 
-	return "", ErrFileHasNoVersion
+func (r MakefileVersionReader) WriteFileVersion(filePath, version string) error {
+	return rewriteFile(filePath, func(content []byte) ([]byte, error) {
+		return replaceSubmatch(content, makefileVersionRegexp, 1, version)
+	})
 }
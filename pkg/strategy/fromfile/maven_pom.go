@@ -1,6 +1,7 @@
 package fromfile
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
 	"os"
@@ -71,6 +72,58 @@ func (r MavenPOMVersionReader) readDirectlyFromPom(filePath string) (string, err
 	return pom.Version, nil
 }
 
+// WriteFileVersion writes the version of the project directly in the POM,
+// leaving the versions of the parent and dependencies untouched.
+func (r MavenPOMVersionReader) WriteFileVersion(filePath, version string) error {
+	return rewriteFile(filePath, func(content []byte) ([]byte, error) {
+		start, end, err := findMavenProjectVersion(content)
+		if err != nil {
+			return nil, err
+		}
+		return spliceBytes(content, start, end, version), nil
+	})
+}
+
+// findMavenProjectVersion returns the offsets of the (trimmed) text of the `project/version` element.
+func findMavenProjectVersion(content []byte) (start, end int, err error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var path []string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, 0, ErrFileHasNoVersion
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			if len(path) != 2 || path[0] != "project" || path[1] != "version" {
+				continue
+			}
+
+			start = int(decoder.InputOffset())
+			token, err = decoder.Token()
+			if err != nil {
+				return 0, 0, err
+			}
+			text, ok := token.(xml.CharData)
+			if !ok {
+				return 0, 0, ErrFileHasNoVersion
+			}
+
+			trimmedText := bytes.TrimSpace(text)
+			if len(trimmedText) == 0 {
+				return 0, 0, ErrFileHasNoVersion
+			}
+			start += bytes.Index(text, trimmedText)
+			end = start + len(trimmedText)
+			return start, end, nil
+		case xml.EndElement:
+			path = path[:len(path)-1]
+		}
+	}
+}
+
 type MavenPOM struct {
 	Version string `xml:"version"`
 }
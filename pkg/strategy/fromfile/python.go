@@ -1,19 +1,41 @@
 package fromfile
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/Masterminds/semver/v3"
 )
 
 var (
-	// pythonRegexp is used to find the call to `setup(..., version='1.2.3', ...)`
-	pythonSetupRegexp = regexp.MustCompile(`setup\((.|\n)*version\s*=\s*['"]{1}(\d|\.)*['"]{1}([^\)]|\n)*\)`)
-	// pythonVersionRegexp is used to find the argument `version='1.2.3'` or `version="1.2.3"`
-	pythonVersionRegexp = regexp.MustCompile(`version\s*=\s*['"](\d*|\.)*['"]`)
+	// pythonSetupRegexp is used to find the call to `setup(..., version='1.2.3', ...)`
+	pythonSetupRegexp = regexp.MustCompile(`setup\((.|\n)*version\s*=\s*['"][^'"]*['"]([^\)]|\n)*\)`)
+	// pythonVersionRegexp is used to find the value of the argument `version='1.2.3'` or `version="1.2.3"`
+	pythonVersionRegexp = regexp.MustCompile(`version\s*=\s*['"]([^'"]*)['"]`)
+	// pythonDunderVersionRegexp is used to find the module attribute `__version__ = "1.2.3"`
+	pythonDunderVersionRegexp = regexp.MustCompile(`(?m)^__version__\s*(?::\s*str\s*)?=\s*['"]([^'"]+)['"]`)
+	// setupCfgSectionRegexp is used to find the sections of a setup.cfg file, such as `[metadata]`
+	setupCfgSectionRegexp = regexp.MustCompile(`^\s*\[([^\]]+)\]`)
+	// setupCfgVersionRegexp is used to find the option `version = 1.2.3` of a setup.cfg file
+	setupCfgVersionRegexp = regexp.MustCompile(`^version\s*[=:]\s*(\S+)`)
+	// pep440Regexp matches the (lenient) PEP 440 versions, such as `1.2.0rc1`, `1.2.0.post1` or `1.2.0.dev3+local`
+	pep440Regexp = regexp.MustCompile(`(?i)^v?(\d+(?:\.\d+)*)` +
+		`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d*))?` +
+		`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+		`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+		`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
 )
 
+// PythonVersionReader reads the version of a Python project, from:
+// - the `[project]` (PEP 621) or `[tool.poetry]` table of a pyproject.toml file
+// - the `[metadata]` section of a setup.cfg file
+// - the `setup(...)` call of a setup.py file
+// - the `__version__` attribute of a module file
+// PEP 440 versions are converted to semver when reading, and back to PEP 440 when writing.
 type PythonVersionReader struct {
 }
 
@@ -23,7 +45,13 @@ func (r PythonVersionReader) String() string {
 
 func (r PythonVersionReader) SupportedFiles() []string {
 	return []string{
+		"pyproject.toml",
+		"setup.cfg",
 		"setup.py",
+		"__version__.py",
+		"_version.py",
+		"version.py",
+		"__init__.py",
 	}
 }
 
@@ -33,23 +61,246 @@ func (r PythonVersionReader) ReadFileVersion(filePath string) (string, error) {
 		return "", err
 	}
 
-	setupCall := pythonSetupRegexp.Find(content)
-	if len(setupCall) == 0 {
-		return "", fmt.Errorf("setup call not found in file %s", filePath)
+	start, end, err := r.findVersion(filePath, content)
+	if err != nil {
+		return "", err
+	}
+
+	return PEP440ToSemver(string(content[start:end]))
+}
+
+func (r PythonVersionReader) WriteFileVersion(filePath, version string) error {
+	pep440Version, err := SemverToPEP440(version)
+	if err != nil {
+		return err
+	}
+
+	return rewriteFile(filePath, func(content []byte) ([]byte, error) {
+		start, end, err := r.findVersion(filePath, content)
+		if err != nil {
+			return nil, err
+		}
+		return spliceBytes(content, start, end, pep440Version), nil
+	})
+}
+
+// findVersion returns the offsets of the version in the content of the given file, based on its name
+func (r PythonVersionReader) findVersion(filePath string, content []byte) (start, end int, err error) {
+	switch filepath.Base(filePath) {
+	case "pyproject.toml":
+		return r.findPyprojectVersion(content)
+	case "setup.cfg":
+		return r.findSetupCfgVersion(content)
+	case "__version__.py", "_version.py", "version.py", "__init__.py":
+		loc := pythonDunderVersionRegexp.FindSubmatchIndex(content)
+		if loc == nil {
+			return 0, 0, ErrFileHasNoVersion
+		}
+		return loc[2], loc[3], nil
+	default:
+		return r.findSetupVersion(filePath, content)
+	}
+}
+
+func (r PythonVersionReader) findPyprojectVersion(content []byte) (start, end int, err error) {
+	entries := parseTOMLEntries(content)
+	for _, table := range []string{"project", "tool.poetry"} {
+		if entry, found := stringTOMLEntry(entries, table, "version"); found {
+			_, start, end, _ := entry.stringValue()
+			return start, end, nil
+		}
 	}
+	// the version might be dynamic, so let's try the next file
+	return 0, 0, ErrFileHasNoVersion
+}
+
+func (r PythonVersionReader) findSetupCfgVersion(content []byte) (start, end int, err error) {
+	var (
+		section string
+		offset  int
+	)
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		lineOffset := offset
+		offset += len(line)
+
+		if matched := setupCfgSectionRegexp.FindStringSubmatch(line); matched != nil {
+			section = strings.TrimSpace(matched[1])
+			continue
+		}
+		if section != "metadata" {
+			continue
+		}
+
+		loc := setupCfgVersionRegexp.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+		if strings.Contains(line[loc[2]:loc[3]], ":") {
+			// such as `version = attr: my_package.__version__`
+			return 0, 0, ErrFileHasNoVersion
+		}
+		return lineOffset + loc[2], lineOffset + loc[3], nil
+	}
+	return 0, 0, ErrFileHasNoVersion
+}
 
-	version := string(pythonVersionRegexp.Find(setupCall))
+func (r PythonVersionReader) findSetupVersion(filePath string, content []byte) (start, end int, err error) {
+	setupCall := pythonSetupRegexp.FindIndex(content)
+	if setupCall == nil && bytes.Contains(content, []byte("setup(")) {
+		// such as a setup.py shim, with the metadata in setup.cfg or pyproject.toml
+		return 0, 0, ErrFileHasNoVersion
+	}
+	if setupCall == nil {
+		return 0, 0, fmt.Errorf("setup call not found in file %s", filePath)
+	}
 
-	parts := strings.Split(strings.ReplaceAll(version, " ", ""), "=")
-	if len(parts) < 2 {
-		return "", fmt.Errorf("version value not found in file %s", filePath)
+	loc := pythonVersionRegexp.FindSubmatchIndex(content[setupCall[0]:setupCall[1]])
+	if loc == nil {
+		return 0, 0, fmt.Errorf("version value not found in file %s", filePath)
+	}
+	if loc[2] == loc[3] {
+		return 0, 0, fmt.Errorf("empty version found in file %s", filePath)
 	}
 
-	v := strings.TrimPrefix(strings.TrimSuffix(parts[1], "'"), "'")
-	v = strings.TrimPrefix(strings.TrimSuffix(v, "\""), "\"")
-	if v == "" {
-		return "", fmt.Errorf("empty version found in file %s", filePath)
+	return setupCall[0] + loc[2], setupCall[0] + loc[3], nil
+}
+
+// PEP440ToSemver converts a PEP 440 version to a semver version:
+// pre-releases and development releases are converted to semver pre-releases,
+// post-releases and local versions are converted to semver build metadata.
+// For example `1.2.0rc1` becomes `1.2.0-rc.1` and `1.2.0.post1` becomes `1.2.0+post.1`.
+// It returns an error for a version which isn't a valid PEP 440 version,
+// or for a release with more than 3 components - such as `1.2.3.4` - which semver can't express.
+func PEP440ToSemver(version string) (string, error) {
+	matched := pep440Regexp.FindStringSubmatch(strings.TrimSpace(version))
+	if matched == nil {
+		return "", fmt.Errorf("the version %q is not a valid PEP 440 version", version)
 	}
+	var (
+		release               = matched[1]
+		preLabel, preNumber   = strings.ToLower(matched[2]), matched[3]
+		postImplicitNumber    = matched[4]
+		postLabel, postNumber = matched[5], matched[6]
+		devLabel, devNumber   = matched[7], matched[8]
+		local                 = matched[9]
+	)
 
-	return v, nil
+	components := strings.Split(release, ".")
+	if len(components) > 3 {
+		return "", fmt.Errorf("the release %s of version %s has more than 3 components, and can't be converted to a semver version", release, version)
+	}
+	for len(components) < 3 {
+		components = append(components, "0")
+	}
+	result := strings.Join(components, ".")
+
+	var prerelease []string
+	if preLabel != "" {
+		switch preLabel {
+		case "a", "alpha":
+			preLabel = "alpha"
+		case "b", "beta":
+			preLabel = "beta"
+		default:
+			preLabel = "rc"
+		}
+		prerelease = append(prerelease, preLabel, defaultNumber(preNumber))
+	}
+	if devLabel != "" {
+		prerelease = append(prerelease, "dev", defaultNumber(devNumber))
+	}
+	if len(prerelease) > 0 {
+		result += "-" + strings.Join(prerelease, ".")
+	}
+
+	var metadata []string
+	switch {
+	case postImplicitNumber != "":
+		metadata = append(metadata, "post", postImplicitNumber)
+	case postLabel != "":
+		metadata = append(metadata, "post", defaultNumber(postNumber))
+	}
+	if local != "" {
+		metadata = append(metadata, strings.FieldsFunc(strings.ToLower(local), func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})...)
+	}
+	if len(metadata) > 0 {
+		result += "+" + strings.Join(metadata, ".")
+	}
+
+	return result, nil
+}
+
+// SemverToPEP440 converts a semver version to a PEP 440 version - this is the reverse of PEP440ToSemver.
+// It returns an error if the version isn't a semver version, or if the pre-release can't be expressed with PEP 440.
+func SemverToPEP440(version string) (string, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("the version %q is not a semver version: %w", version, err)
+	}
+
+	result := fmt.Sprintf("%d.%d.%d", v.Major(), v.Minor(), v.Patch())
+
+	var dev string
+	if v.Prerelease() != "" {
+		parts := strings.Split(v.Prerelease(), ".")
+		for i := 0; i < len(parts); i++ {
+			label, number := parts[i], "0"
+			if i+1 < len(parts) && isNumber(parts[i+1]) {
+				number = parts[i+1]
+				i++
+			}
+			switch label {
+			case "alpha", "a":
+				result += "a" + number
+			case "beta", "b":
+				result += "b" + number
+			case "rc", "c":
+				result += "rc" + number
+			case "dev":
+				dev = ".dev" + number
+			default:
+				return "", fmt.Errorf("the pre-release %q of version %s can't be converted to a PEP 440 version", v.Prerelease(), version)
+			}
+		}
+	}
+
+	var local []string
+	if v.Metadata() != "" {
+		parts := strings.Split(v.Metadata(), ".")
+		for i := 0; i < len(parts); i++ {
+			if parts[i] == "post" && i+1 < len(parts) && isNumber(parts[i+1]) {
+				result += ".post" + parts[i+1]
+				i++
+				continue
+			}
+			local = append(local, parts[i])
+		}
+	}
+	result += dev
+	if len(local) > 0 {
+		result += "+" + strings.Join(local, ".")
+	}
+
+	return result, nil
+}
+
+func defaultNumber(number string) string {
+	if number == "" {
+		return "0"
+	}
+	return number
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
 }
@@ -37,6 +37,36 @@ func TestPythonVersionReader(t *testing.T) {
 			filePath: "setup-double-quotes.py",
 			expected: "1.2.14",
 		},
+		{
+			name:     "prerelease",
+			filePath: "setup-prerelease.py",
+			expected: "1.3.0-beta.2",
+		},
+		{
+			name:     "pyproject.toml PEP 621",
+			filePath: "python/pyproject.toml",
+			expected: "1.3.0-rc.1",
+		},
+		{
+			name:     "pyproject.toml poetry",
+			filePath: "python/poetry/pyproject.toml",
+			expected: "1.2.17+post.1",
+		},
+		{
+			name:     "setup.cfg",
+			filePath: "python/setup-cfg/setup.cfg",
+			expected: "1.2.18",
+		},
+		{
+			name:     "module __version__",
+			filePath: "python/my_package/__init__.py",
+			expected: "1.2.19-dev.2",
+		},
+		{
+			name:             "setup.py without version",
+			filePath:         "python/setup-cfg/setup.py",
+			expectedErrorMsg: "the file has no version",
+		},
 		{
 			name:             "file does not exists",
 			filePath:         "does-not-exists.yaml",
@@ -66,3 +96,81 @@ func TestPythonVersionReader(t *testing.T) {
 		})
 	}
 }
+
+func TestPEP440ToSemver(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pep440           string
+		semver           string
+		expectedErrorMsg string
+	}{
+		{pep440: "1.2.3", semver: "1.2.3"},
+		{pep440: "1.2", semver: "1.2.0"},
+		{pep440: "v1.2.3", semver: "1.2.3"},
+		{pep440: "1.2.0rc1", semver: "1.2.0-rc.1"},
+		{pep440: "1.2.0-rc.1", semver: "1.2.0-rc.1"},
+		{pep440: "1.2.0a1", semver: "1.2.0-alpha.1"},
+		{pep440: "1.2.0b", semver: "1.2.0-beta.0"},
+		{pep440: "1.2.0.post1", semver: "1.2.0+post.1"},
+		{pep440: "1.2.0-1", semver: "1.2.0+post.1"},
+		{pep440: "1.2.0.dev3", semver: "1.2.0-dev.3"},
+		{pep440: "1.2.0rc1.dev3", semver: "1.2.0-rc.1.dev.3"},
+		{pep440: "1.2.0+ubuntu-1", semver: "1.2.0+ubuntu.1"},
+		{pep440: "not a version", expectedErrorMsg: `the version "not a version" is not a valid PEP 440 version`},
+		{pep440: "1.2.3-SNAPSHOT", expectedErrorMsg: `the version "1.2.3-SNAPSHOT" is not a valid PEP 440 version`},
+		{pep440: "1.2.3.4", expectedErrorMsg: "the release 1.2.3.4 of version 1.2.3.4 has more than 3 components, and can't be converted to a semver version"},
+		{pep440: "2021.1.2.3rc1", expectedErrorMsg: "the release 2021.1.2.3 of version 2021.1.2.3rc1 has more than 3 components, and can't be converted to a semver version"},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.pep440, func(t *testing.T) {
+			t.Parallel()
+			actual, err := PEP440ToSemver(test.pep440)
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Empty(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.semver, actual)
+			}
+		})
+	}
+}
+
+func TestSemverToPEP440(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		semver           string
+		pep440           string
+		expectedErrorMsg string
+	}{
+		{semver: "1.2.3", pep440: "1.2.3"},
+		{semver: "1.2.0-rc.1", pep440: "1.2.0rc1"},
+		{semver: "1.2.0-alpha.1", pep440: "1.2.0a1"},
+		{semver: "1.2.0-beta", pep440: "1.2.0b0"},
+		{semver: "1.2.0+post.1", pep440: "1.2.0.post1"},
+		{semver: "1.2.0-dev.3", pep440: "1.2.0.dev3"},
+		{semver: "1.2.0-rc.1.dev.3+post.2", pep440: "1.2.0rc1.post2.dev3"},
+		{semver: "1.2.0+ubuntu.1", pep440: "1.2.0+ubuntu.1"},
+		{semver: "not a version", expectedErrorMsg: `the version "not a version" is not a semver version: invalid semantic version`},
+		{semver: "1.2.0-SNAPSHOT", expectedErrorMsg: "the pre-release \"SNAPSHOT\" of version 1.2.0-SNAPSHOT can't be converted to a PEP 440 version"},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.semver, func(t *testing.T) {
+			t.Parallel()
+			actual, err := SemverToPEP440(test.semver)
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Empty(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.pep440, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+"""A test module for testing"""
+
+__version__ = "1.2.19.dev2"
+__all__ = ["__version__"]
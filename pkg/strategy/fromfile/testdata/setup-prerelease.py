@@ -0,0 +1,7 @@
+from setuptools import setup
+
+setup(
+    name='jx-release-version',
+    version='1.3.0b2',
+    description='A test setup.py script for testing',
+)
@@ -0,0 +1,138 @@
+package fromfile
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+var (
+	tomlTableRegexp = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?\s*(#.*)?$`)
+	tomlKeyRegexp   = regexp.MustCompile(`^\s*([A-Za-z0-9_\-."' ]+?)\s*=\s*`)
+)
+
+// tomlEntry is a `key = value` line of a TOML file.
+// We don't need a full TOML parser to read or write a version:
+// working at the line level preserves the formatting and comments of the file.
+type tomlEntry struct {
+	// table is the name of the table the entry belongs to, such as `package` or `workspace.package`
+	table string
+	// key is the (dotted) key of the entry, such as `version` or `version.workspace`
+	key string
+	// value is the raw value, such as `"1.2.3"`, `true` or `{ workspace = true }`
+	value string
+	// valueStart and valueEnd are the offsets of the raw value in the file content
+	valueStart, valueEnd int
+}
+
+// parseTOMLEntries returns all the single-line `key = value` entries of a TOML file
+func parseTOMLEntries(content []byte) []tomlEntry {
+	var (
+		entries []tomlEntry
+		table   string
+		offset  int
+	)
+
+	for _, rawLine := range bytes.Split(content, []byte("\n")) {
+		line := strings.TrimSuffix(string(rawLine), "\r")
+		lineOffset := offset
+		offset += len(rawLine) + 1
+
+		if matched := tomlTableRegexp.FindStringSubmatch(line); matched != nil {
+			table = normalizeTOMLKey(matched[1])
+			continue
+		}
+
+		loc := tomlKeyRegexp.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+
+		value := stripTOMLComment(line[loc[1]:])
+		entries = append(entries, tomlEntry{
+			table:      table,
+			key:        normalizeTOMLKey(line[loc[2]:loc[3]]),
+			value:      value,
+			valueStart: lineOffset + loc[1],
+			valueEnd:   lineOffset + loc[1] + len(value),
+		})
+	}
+
+	return entries
+}
+
+// lookupTOMLEntry returns the entry with the given table and key
+func lookupTOMLEntry(entries []tomlEntry, table, key string) (tomlEntry, bool) {
+	for _, entry := range entries {
+		if entry.table == table && entry.key == key {
+			return entry, true
+		}
+	}
+	return tomlEntry{}, false
+}
+
+// stringTOMLEntry returns the entry with the given table and key, only if its value is a string
+func stringTOMLEntry(entries []tomlEntry, table, key string) (tomlEntry, bool) {
+	entry, found := lookupTOMLEntry(entries, table, key)
+	if !found {
+		return tomlEntry{}, false
+	}
+	if _, _, _, ok := entry.stringValue(); !ok {
+		return tomlEntry{}, false
+	}
+	return entry, true
+}
+
+// hasTOMLTable returns true if at least 1 entry belongs to the given table - or one of its sub-tables
+func hasTOMLTable(content []byte, table string) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		matched := tomlTableRegexp.FindStringSubmatch(scanner.Text())
+		if matched == nil {
+			continue
+		}
+		name := normalizeTOMLKey(matched[1])
+		if name == table || strings.HasPrefix(name, table+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// stringValue returns the unquoted value of a string entry, and the offsets of the value without its quotes
+func (e tomlEntry) stringValue() (value string, start, end int, ok bool) {
+	if len(e.value) < 2 {
+		return "", 0, 0, false
+	}
+	quote := e.value[0]
+	if (quote != '"' && quote != '\'') || e.value[len(e.value)-1] != quote {
+		return "", 0, 0, false
+	}
+	return e.value[1 : len(e.value)-1], e.valueStart + 1, e.valueEnd - 1, true
+}
+
+// normalizeTOMLKey removes the quotes and spaces around the parts of a dotted key
+func normalizeTOMLKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(parts[i]), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// stripTOMLComment removes any trailing comment - outside of a string - from a raw value
+func stripTOMLComment(value string) string {
+	var quote rune
+	for i, c := range value {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return strings.TrimSpace(value[:i])
+		}
+	}
+	return strings.TrimSpace(value)
+}
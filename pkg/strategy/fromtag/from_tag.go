@@ -1,31 +1,58 @@
 package fromtag
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/gitauth"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 )
 
+// VersionGroup is the name of the capture group of the tag pattern used as the version, such as `^my-chart-(?P<version>.+)$`
+const VersionGroup = "version"
+
+// peeledSuffix is the suffix of the peeled references of the annotated tags - the commits they point to
+const peeledSuffix = "^{}"
+
 var (
 	ErrNoTags       = errors.New("the git repository has no tags")
 	ErrNoSemverTags = errors.New("the git repository has no semver tags")
 )
 
 type Strategy struct {
-	Dir        string
+	Dir string
+	// TagPattern filters the tags. If it has a `version` named capture group, the group is used as the version.
 	TagPattern string
-	FetchTags  bool
+	// TagPrefix is removed from the tags before parsing them - such as `release-1.2.3`. The tags without the prefix are parsed as-is.
+	TagPrefix string
+	// PrefixedOnly only uses the tags with the TagPrefix - such as the tags of a component of a monorepo - in addition to the TagPattern
+	PrefixedOnly bool
+	FetchTags    bool
+	// RemoteTags reads the previous version from the tags of the remote instead of the local tags,
+	// without fetching them all: only the tag of the previous version is fetched.
+	RemoteTags bool
+	// ReachableOnly only uses the tags whose commits are reachable from HEAD - ignoring the tags of unmerged branches
+	ReachableOnly bool
+	// Nearest uses the tag nearest to HEAD - by number of commits - instead of the highest version. It implies ReachableOnly.
+	Nearest bool
+	// GitAuth is the remote the tags are fetched from, and its authentication
+	GitAuth gitauth.Config
+	// Decision records the tag of the previous version - if set
+	Decision *strategy.Decision
 }
 
-func (s Strategy) ReadVersion() (*semver.Version, error) {
+func (s Strategy) ReadVersion(ctx context.Context) (*semver.Version, error) {
 	var (
 		dir = s.Dir
 		err error
@@ -50,15 +77,31 @@ func (s Strategy) ReadVersion() (*semver.Version, error) {
 		return nil, fmt.Errorf("failed to open git repository at %q: %w", dir, err)
 	}
 
+	if s.RemoteTags {
+		return s.readRemoteVersion(ctx, repo, tagRegexp)
+	}
+
 	if s.FetchTags {
-		log.Logger().Debug("Fetching tags from origin")
-		err = repo.Fetch(&git.FetchOptions{
-			RemoteName: "origin",
+		remoteName := s.GitAuth.RemoteName()
+		log.Logger().Debugf("Fetching tags from %s", remoteName)
+		remote, err := s.GitAuth.OpenRemote(repo)
+		if err != nil {
+			return nil, err
+		}
+		auth, err := s.GitAuth.Auth(ctx, repo, remote)
+		if err != nil {
+			return nil, err
+		}
+		fetchCtx, cancel := s.GitAuth.Context(ctx)
+		defer cancel()
+		err = remote.FetchContext(fetchCtx, &git.FetchOptions{
+			RemoteName: remote.Config().Name,
 			Progress:   os.Stderr,
 			RefSpecs:   []config.RefSpec{config.RefSpec("refs/tags/*:refs/tags/*")},
+			Auth:       auth,
 		})
 		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
-			return nil, fmt.Errorf("failed to fetch tags from origin at %q: %w", dir, err)
+			return nil, fmt.Errorf("failed to fetch tags from %s at %q: %w", remoteName, dir, err)
 		}
 	}
 
@@ -67,42 +110,271 @@ func (s Strategy) ReadVersion() (*semver.Version, error) {
 		return nil, fmt.Errorf("failed to list tags from git repository at %q: %w", dir, err)
 	}
 
+	var refs []*plumbing.Reference
+	err = tagIterator.ForEach(func(ref *plumbing.Reference) error {
+		refs = append(refs, ref)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterator over tags from git repository at %q: %w", dir, err)
+	}
+
+	commitOf := func(ref *plumbing.Reference) string {
+		return tagCommitHash(repo, ref)
+	}
+	version, ref, err := s.previousVersion(ctx, repo, refs, tagRegexp, commitOf)
+	if err != nil {
+		return nil, err
+	}
+	s.Decision.SetPreviousTag(ref.Name().Short(), commitOf(ref))
+	return version, nil
+}
+
+// readRemoteVersion reads the previous version from the tags of the remote, listed without fetching them - like `git ls-remote --tags`.
+// Only the tag of the previous version is fetched, so that its commit is available to the other strategies.
+func (s Strategy) readRemoteVersion(ctx context.Context, repo *git.Repository, tagRegexp *regexp.Regexp) (*semver.Version, error) {
+	remoteName := s.GitAuth.RemoteName()
+	remote, err := s.GitAuth.OpenRemote(repo)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := s.GitAuth.Auth(ctx, repo, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Logger().Debugf("Listing the tags of %s", remoteName)
+	listCtx, cancel := s.GitAuth.Context(ctx)
+	defer cancel()
+	remoteRefs, err := remote.ListContext(listCtx, &git.ListOptions{Auth: auth, PeelingOption: git.AppendPeeled})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return nil, ErrNoTags
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tags of %s: %w", remoteName, err)
+	}
+
 	var (
-		tags     int
-		versions []semver.Version
+		refs []*plumbing.Reference
+		// commits are the commit hashes of the annotated tags, by tag reference name
+		commits = map[plumbing.ReferenceName]plumbing.Hash{}
 	)
-	err = tagIterator.ForEach(func(ref *plumbing.Reference) error {
-		tags++
+	for _, ref := range remoteRefs {
+		if !ref.Name().IsTag() {
+			continue
+		}
+		if name, found := strings.CutSuffix(ref.Name().String(), peeledSuffix); found {
+			commits[plumbing.ReferenceName(name)] = ref.Hash()
+			continue
+		}
+		refs = append(refs, ref)
+	}
+
+	commitOf := func(ref *plumbing.Reference) string {
+		if commit, found := commits[ref.Name()]; found {
+			return commit.String()
+		}
+		// it's a lightweight tag
+		return ref.Hash().String()
+	}
+	version, ref, err := s.previousVersion(ctx, repo, refs, tagRegexp, commitOf)
+	if err != nil {
+		return nil, err
+	}
+
+	if local, err := repo.Reference(ref.Name(), false); err == nil && local.Hash() == ref.Hash() {
+		log.Logger().Debugf("Tag %s of %s already exists locally", ref.Name().Short(), remoteName)
+	} else {
+		log.Logger().Debugf("Fetching tag %s from %s", ref.Name().Short(), remoteName)
+		// in a shallow clone, only the commit of the tag is fetched - not its whole history.
+		// A full clone already has most of the history, and must not become shallow.
+		depth := 0
+		if shallow, err := repo.Storer.Shallow(); err == nil && len(shallow) > 0 {
+			depth = 1
+		}
+		fetchCtx, cancel := s.GitAuth.Context(ctx)
+		defer cancel()
+		err = remote.FetchContext(fetchCtx, &git.FetchOptions{
+			RemoteName: remote.Config().Name,
+			RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:%s", ref.Name(), ref.Name()))},
+			Depth:      depth,
+			Tags:       git.NoTags,
+			Auth:       auth,
+		})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return nil, fmt.Errorf("failed to fetch tag %s from %s: %w", ref.Name().Short(), remoteName, err)
+		}
+	}
+
+	s.Decision.SetPreviousTag(ref.Name().Short(), commitOf(ref))
+	return version, nil
+}
+
+// tagVersion is the version of a tag
+type tagVersion struct {
+	version semver.Version
+	ref     *plumbing.Reference
+}
+
+// previousVersion returns the version of the previous release among the semver tags matching the pattern - the highest one,
+// or the nearest one from HEAD - and its tag. commitOf returns the hash of the commit of a tag.
+func (s Strategy) previousVersion(ctx context.Context, repo *git.Repository, refs []*plumbing.Reference, tagRegexp *regexp.Regexp, commitOf func(*plumbing.Reference) string) (*semver.Version, *plumbing.Reference, error) {
+	// the versions are kept with their tags: tags with different prefixes can have the same version
+	var versions []tagVersion
+	for _, ref := range refs {
 		tag := ref.Name().Short()
+		if s.PrefixedOnly && !strings.HasPrefix(tag, s.TagPrefix) {
+			log.Logger().Debugf("Skipping tag %q without prefix %q", tag, s.TagPrefix)
+			continue
+		}
 		if tagRegexp != nil && !tagRegexp.MatchString(tag) {
 			log.Logger().Debugf("Skipping tag %q not matching pattern %q", tag, s.TagPattern)
-			return nil
+			continue
 		}
-		v, err := semver.NewVersion(tag)
+		v, err := s.parseVersion(tag, tagRegexp)
 		if err != nil {
 			log.Logger().Debugf("Skipping non-semver tag %q (%s)", tag, err)
-			return nil
+			continue
 		}
-		versions = append(versions, *v)
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to iterator over tags from git repository at %q: %w", dir, err)
+		versions = append(versions, tagVersion{version: *v, ref: ref})
 	}
-	if tags == 0 {
-		return nil, ErrNoTags
+	if len(refs) == 0 {
+		return nil, nil, ErrNoTags
 	}
-	if len(versions) == 0 && s.TagPattern == "" {
-		return nil, ErrNoSemverTags
+	if len(versions) == 0 && s.TagPattern == "" && !s.PrefixedOnly {
+		return nil, nil, ErrNoSemverTags
 	}
 	if len(versions) == 0 {
-		return nil, fmt.Errorf("no semver tags with pattern %q found", s.TagPattern)
+		return nil, nil, noMatchingTagsError{pattern: s.TagPattern, prefix: s.prefixFilter()}
 	}
 	log.Logger().Debugf("Found %d semver tags with pattern %q", len(versions), s.TagPattern)
 
+	var distances map[string]int
+	if s.ReachableOnly || s.Nearest {
+		var err error
+		distances, err = distancesFromHead(ctx, repo)
+		if err != nil {
+			return nil, nil, err
+		}
+		reachable := versions[:0]
+		for _, v := range versions {
+			if _, found := distances[commitOf(v.ref)]; !found {
+				log.Logger().Debugf("Skipping tag %q not reachable from HEAD", v.ref.Name().Short())
+				continue
+			}
+			reachable = append(reachable, v)
+		}
+		versions = reachable
+		if len(versions) == 0 {
+			return nil, nil, fmt.Errorf("%w reachable from HEAD", ErrNoSemverTags)
+		}
+	}
+
 	sort.SliceStable(versions, func(i, j int) bool {
-		return versions[i].GreaterThan(&versions[j])
+		if s.Nearest {
+			di := distances[commitOf(versions[i].ref)]
+			dj := distances[commitOf(versions[j].ref)]
+			if di != dj {
+				return di < dj
+			}
+		}
+		return versions[i].version.GreaterThan(&versions[j].version)
 	})
+	return &versions[0].version, versions[0].ref, nil
+}
+
+// prefixFilter returns the prefix the tags must have - if any
+func (s Strategy) prefixFilter() string {
+	if !s.PrefixedOnly {
+		return ""
+	}
+	return s.TagPrefix
+}
+
+// distancesFromHead returns the hashes of the commits reachable from HEAD, with their distance: the minimum number of commits from HEAD.
+// Unretrievable commits - in a shallow clone for example - are ignored. The walk stops when the context is canceled.
+func distancesFromHead(ctx context.Context, repo *git.Repository) (map[string]int, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the HEAD reference: %w", err)
+	}
+
+	var (
+		queue     = []plumbing.Hash{head.Hash()}
+		distances = map[string]int{head.Hash().String(): 0}
+	)
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hash := queue[0]
+		queue = queue[1:]
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			continue
+		}
+		for _, parent := range commit.ParentHashes {
+			if _, found := distances[parent.String()]; !found {
+				distances[parent.String()] = distances[hash.String()] + 1
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return distances, nil
+}
+
+// parseVersion returns the version of a tag: the version capture group of the tag pattern - if any -
+// or the tag without its prefix, or the tag itself
+func (s Strategy) parseVersion(tag string, tagRegexp *regexp.Regexp) (*semver.Version, error) {
+	if tagRegexp != nil {
+		if group := tagRegexp.SubexpIndex(VersionGroup); group >= 0 {
+			return semver.NewVersion(tagRegexp.FindStringSubmatch(tag)[group])
+		}
+	}
+
+	if s.TagPrefix != "" && strings.HasPrefix(tag, s.TagPrefix) {
+		if v, err := semver.NewVersion(strings.TrimPrefix(tag, s.TagPrefix)); err == nil {
+			// keep the tag as the original version when it's the same version - such as `v1.2.3` with the `v` prefix
+			if original, err := semver.NewVersion(tag); err == nil && original.Equal(v) && original.Metadata() == v.Metadata() {
+				return original, nil
+			}
+			return v, nil
+		}
+	}
+	return semver.NewVersion(tag)
+}
+
+// tagCommitHash returns the hash of the commit of a lightweight or annotated tag
+func tagCommitHash(repo *git.Repository, ref *plumbing.Reference) string {
+	tag, err := repo.TagObject(ref.Hash())
+	if err != nil {
+		// it's a lightweight tag
+		return ref.Hash().String()
+	}
+	commit, err := tag.Commit()
+	if err != nil {
+		return ""
+	}
+	return commit.Hash.String()
+}
+
+// noMatchingTagsError is returned when the git repository has semver tags, but none of them match the tag pattern
+type noMatchingTagsError struct {
+	pattern string
+	prefix  string
+}
+
+func (e noMatchingTagsError) Error() string {
+	switch {
+	case e.prefix == "":
+		return fmt.Sprintf("no semver tags with pattern %q found", e.pattern)
+	case e.pattern == "":
+		return fmt.Sprintf("no semver tags with prefix %q found", e.prefix)
+	default:
+		return fmt.Sprintf("no semver tags with prefix %q and pattern %q found", e.prefix, e.pattern)
+	}
+}
 
-	return &versions[0], nil
+func (e noMatchingTagsError) Is(target error) bool {
+	return target == ErrNoSemverTags
 }
@@ -1,13 +1,21 @@
 package fromtag
 
 import (
+	"context"
+	"net"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/gitauth"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
 	archiver "github.com/jm33-m0/arc/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -89,7 +97,7 @@ func TestReadVersion(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
-			actual, err := test.strategy.ReadVersion()
+			actual, err := test.strategy.ReadVersion(context.Background())
 			if test.expectedErrorMsg != "" {
 				require.EqualError(t, err, test.expectedErrorMsg)
 				assert.Nil(t, actual)
@@ -100,3 +108,456 @@ func TestReadVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestReadVersionWithTagPrefix(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	signature := &object.Signature{Name: "test", Email: "test@example.com"}
+	hash, err := w.Commit("chore: init", &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+	require.NoError(t, err)
+	for _, tagName := range []string{"1.5.0", "api/v1.2.0", "api/v1.3.0", "web/v2.0.0", "release-1.4.0", "my-chart-3.1.0", "my-chart-latest", "v2-3.1.0"} {
+		_, err = repo.CreateTag(tagName, hash, nil)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name             string
+		strategy         Strategy
+		expected         *semver.Version
+		expectedErrorMsg string
+	}{
+		{
+			name: "component tags",
+			strategy: Strategy{
+				Dir:        dir,
+				TagPattern: "^api/v",
+				TagPrefix:  "api/v",
+			},
+			expected: semver.MustParse("1.3.0"),
+		},
+		{
+			name: "no component tags",
+			strategy: Strategy{
+				Dir:        dir,
+				TagPattern: "^docs/v",
+				TagPrefix:  "docs/v",
+			},
+			expectedErrorMsg: "no semver tags with pattern \"^docs/v\" found",
+		},
+		{
+			name: "prefix without pattern",
+			strategy: Strategy{
+				Dir:       dir,
+				TagPrefix: "web/v",
+			},
+			expected: semver.MustParse("2.0.0"),
+		},
+		{
+			name: "prefix of non-semver tags",
+			strategy: Strategy{
+				Dir:        dir,
+				TagPattern: "^release-",
+				TagPrefix:  "release-",
+			},
+			expected: semver.MustParse("1.4.0"),
+		},
+		{
+			name: "prefix of semver tags",
+			strategy: Strategy{
+				Dir:        dir,
+				TagPattern: "^v2-",
+				TagPrefix:  "v2-",
+			},
+			expected: semver.MustParse("3.1.0"),
+		},
+		{
+			name: "prefixed tags only",
+			strategy: Strategy{
+				Dir:          dir,
+				TagPattern:   `^api/v1\.2\.|^web/`,
+				TagPrefix:    "api/v",
+				PrefixedOnly: true,
+			},
+			expected: semver.MustParse("1.2.0"),
+		},
+		{
+			name: "no prefixed tags",
+			strategy: Strategy{
+				Dir:          dir,
+				TagPrefix:    "docs/v",
+				PrefixedOnly: true,
+			},
+			expectedErrorMsg: "no semver tags with prefix \"docs/v\" found",
+		},
+		{
+			name: "version capture group",
+			strategy: Strategy{
+				Dir:        dir,
+				TagPattern: "^my-chart-(?P<version>.+)$",
+				TagPrefix:  "v",
+			},
+			expected: semver.MustParse("3.1.0"),
+		},
+		{
+			name: "no semver version in the capture group",
+			strategy: Strategy{
+				Dir:        dir,
+				TagPattern: "^my-chart-(?P<version>[a-z]+)$",
+			},
+			expectedErrorMsg: "no semver tags with pattern \"^my-chart-(?P<version>[a-z]+)$\" found",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := test.strategy.ReadVersion(context.Background())
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.ErrorIs(t, err, ErrNoSemverTags)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestReadVersionFromRemoteTags(t *testing.T) {
+	t.Parallel()
+
+	remoteDir := t.TempDir()
+	remoteRepo, err := git.PlainInit(remoteDir, false)
+	require.NoError(t, err)
+	w, err := remoteRepo.Worktree()
+	require.NoError(t, err)
+	signature := &object.Signature{Name: "test", Email: "test@example.com"}
+	first, err := w.Commit("chore: init", &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+	require.NoError(t, err)
+	_, err = remoteRepo.CreateTag("v1.0.0", first, nil)
+	require.NoError(t, err)
+	_, err = remoteRepo.CreateTag("not-semver", first, nil)
+	require.NoError(t, err)
+
+	// the local clones don't have the tags, nor the last commit of the remote
+	localDirs := map[string]string{}
+	for _, name := range []string{"highest version", "tag pattern", "no matching tags"} {
+		localDirs[name] = t.TempDir()
+		_, err = git.PlainClone(localDirs[name], false, &git.CloneOptions{URL: remoteDir, Tags: git.NoTags})
+		require.NoError(t, err)
+	}
+
+	second, err := w.Commit("feat: something", &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+	require.NoError(t, err)
+	_, err = remoteRepo.CreateTag("v1.1.0", second, &git.CreateTagOptions{Tagger: signature, Message: "Release version v1.1.0"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		tagPattern       string
+		expected         *semver.Version
+		expectedTag      string
+		expectedCommit   string
+		expectedErrorMsg string
+	}{
+		{
+			name:           "highest version",
+			expected:       semver.MustParse("v1.1.0"),
+			expectedTag:    "v1.1.0",
+			expectedCommit: second.String(),
+		},
+		{
+			name:           "tag pattern",
+			tagPattern:     "^v1.0",
+			expected:       semver.MustParse("v1.0.0"),
+			expectedTag:    "v1.0.0",
+			expectedCommit: first.String(),
+		},
+		{
+			name:             "no matching tags",
+			tagPattern:       "^v2",
+			expectedErrorMsg: "no semver tags with pattern \"^v2\" found",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			decision := &strategy.Decision{}
+			s := Strategy{
+				Dir:        localDirs[test.name],
+				TagPattern: test.tagPattern,
+				RemoteTags: true,
+				GitAuth:    gitauth.Config{Remote: "file://" + remoteDir},
+				Decision:   decision,
+			}
+			actual, err := s.ReadVersion(context.Background())
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+			assert.Equal(t, test.expectedTag, decision.PreviousTag)
+			assert.Equal(t, test.expectedCommit, decision.PreviousCommit)
+
+			// only the tag of the previous version is fetched, with its commit
+			localRepo, err := git.PlainOpen(localDirs[test.name])
+			require.NoError(t, err)
+			tags, err := localRepo.Tags()
+			require.NoError(t, err)
+			var tagNames []string
+			require.NoError(t, tags.ForEach(func(ref *plumbing.Reference) error {
+				tagNames = append(tagNames, ref.Name().Short())
+				return nil
+			}))
+			assert.Equal(t, []string{test.expectedTag}, tagNames)
+			_, err = localRepo.CommitObject(plumbing.NewHash(test.expectedCommit))
+			assert.NoError(t, err)
+			// the full clone stays a full clone
+			shallow, err := localRepo.Storer.Shallow()
+			require.NoError(t, err)
+			assert.Empty(t, shallow)
+		})
+	}
+}
+
+func TestReadVersionFromRemoteTagsInShallowClone(t *testing.T) {
+	t.Parallel()
+
+	remoteDir := t.TempDir()
+	remoteRepo, err := git.PlainInit(remoteDir, false)
+	require.NoError(t, err)
+	w, err := remoteRepo.Worktree()
+	require.NoError(t, err)
+	signature := &object.Signature{Name: "test", Email: "test@example.com"}
+	_, err = w.Commit("chore: init", &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+	require.NoError(t, err)
+
+	localDir := t.TempDir()
+	_, err = git.PlainClone(localDir, false, &git.CloneOptions{URL: "file://" + remoteDir, Depth: 1, Tags: git.NoTags})
+	require.NoError(t, err)
+
+	// the history of the tag is not in the shallow clone
+	parent, err := w.Commit("feat: something", &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+	require.NoError(t, err)
+	tagged, err := w.Commit("fix: something", &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+	require.NoError(t, err)
+	_, err = remoteRepo.CreateTag("v1.1.0", tagged, nil)
+	require.NoError(t, err)
+
+	s := Strategy{
+		Dir:        localDir,
+		RemoteTags: true,
+		GitAuth:    gitauth.Config{Remote: "file://" + remoteDir},
+	}
+	actual, err := s.ReadVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, semver.MustParse("v1.1.0"), actual)
+
+	// only the commit of the tag is fetched
+	localRepo, err := git.PlainOpen(localDir)
+	require.NoError(t, err)
+	_, err = localRepo.CommitObject(tagged)
+	assert.NoError(t, err)
+	_, err = localRepo.CommitObject(parent)
+	assert.ErrorIs(t, err, plumbing.ErrObjectNotFound)
+}
+
+func TestReadVersionReachableFromHead(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	signature := &object.Signature{Name: "test", Email: "test@example.com"}
+	commit := func(message string, parents ...plumbing.Hash) plumbing.Hash {
+		hash, err := w.Commit(message, &git.CommitOptions{Author: signature, Committer: signature, Parents: parents, AllowEmptyCommits: true})
+		require.NoError(t, err)
+		return hash
+	}
+	// a side branch with a pre-release tag, and a main branch with an older release line tag nearer to HEAD
+	base := commit("chore: init")
+	_, err = repo.CreateTag("v1.1.0", base, &git.CreateTagOptions{Tagger: signature, Message: "Release version v1.1.0"})
+	require.NoError(t, err)
+	side := commit("feat!: breaking change on a side branch", base)
+	_, err = repo.CreateTag("v2.0.0-beta.1", side, nil)
+	require.NoError(t, err)
+	backport := commit("fix: merge the 1.0 release line", base)
+	_, err = repo.CreateTag("v1.0.5", backport, nil)
+	require.NoError(t, err)
+	// the same version with different prefixes, only one of them reachable
+	_, err = repo.CreateTag("a/v3.0.0", backport, nil)
+	require.NoError(t, err)
+	_, err = repo.CreateTag("b/v3.0.0", side, nil)
+	require.NoError(t, err)
+	commit("fix: on the main branch", backport)
+
+	tests := []struct {
+		name             string
+		strategy         Strategy
+		expected         *semver.Version
+		expectedErrorMsg string
+	}{
+		{
+			name:     "all tags",
+			strategy: Strategy{Dir: dir},
+			expected: semver.MustParse("v2.0.0-beta.1"),
+		},
+		{
+			name:     "reachable tags",
+			strategy: Strategy{Dir: dir, ReachableOnly: true},
+			expected: semver.MustParse("v1.1.0"),
+		},
+		{
+			name:     "nearest tag",
+			strategy: Strategy{Dir: dir, Nearest: true},
+			expected: semver.MustParse("v1.0.5"),
+		},
+		{
+			name:     "reachable tag among the same versions",
+			strategy: Strategy{Dir: dir, TagPattern: `^[ab]/v(?P<version>.+)$`, ReachableOnly: true},
+			expected: semver.MustParse("3.0.0"),
+		},
+		{
+			name:             "no reachable tags",
+			strategy:         Strategy{Dir: dir, TagPattern: "^v2", ReachableOnly: true},
+			expectedErrorMsg: "the git repository has no semver tags reachable from HEAD",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := test.strategy.ReadVersion(context.Background())
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.ErrorIs(t, err, ErrNoSemverTags)
+				assert.Nil(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+
+	// the walk of the commits stops with the context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = distancesFromHead(ctx, repo)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReadVersionFromSlowRemote(t *testing.T) {
+	t.Parallel()
+
+	remoteURL := slowRemote(t)
+
+	tests := []struct {
+		name       string
+		fetchTags  bool
+		remoteTags bool
+		timeout    time.Duration
+		canceled   bool
+		expected   error
+	}{
+		{
+			name:      "fetch tags timeout",
+			fetchTags: true,
+			timeout:   100 * time.Millisecond,
+			expected:  context.DeadlineExceeded,
+		},
+		{
+			name:       "remote tags timeout",
+			remoteTags: true,
+			timeout:    100 * time.Millisecond,
+			expected:   context.DeadlineExceeded,
+		},
+		{
+			name:      "canceled context",
+			fetchTags: true,
+			canceled:  true,
+			expected:  context.Canceled,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			_, err := git.PlainInit(dir, false)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.canceled {
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					cancel()
+				}()
+			}
+
+			s := Strategy{
+				Dir:        dir,
+				FetchTags:  test.fetchTags,
+				RemoteTags: test.remoteTags,
+				GitAuth:    gitauth.Config{Remote: remoteURL, Timeout: test.timeout},
+			}
+			start := time.Now()
+			actual, err := s.ReadVersion(ctx)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, test.expected)
+			assert.Nil(t, actual)
+			assert.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+}
+
+// slowRemote starts a local server which accepts the connections but never answers, and returns the URL of its git remote
+func slowRemote(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	var (
+		mutex sync.Mutex
+		conns []net.Conn
+	)
+	t.Cleanup(func() {
+		_ = listener.Close()
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mutex.Lock()
+			conns = append(conns, conn)
+			mutex.Unlock()
+		}
+	}()
+	return "http://" + listener.Addr().String() + "/repo.git"
+}
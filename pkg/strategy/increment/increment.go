@@ -1,28 +1,35 @@
 package increment
 
 import (
+	"context"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 )
 
 type Strategy struct {
 	ComponentToIncrement string
+	// Decision records the incremented component - if set
+	Decision *strategy.Decision
 }
 
-func (s Strategy) BumpVersion(previous semver.Version) (*semver.Version, error) {
+func (s Strategy) BumpVersion(_ context.Context, previous semver.Version) (*semver.Version, error) {
 	var next semver.Version
 	switch strings.ToLower(s.ComponentToIncrement) {
 	case "major":
 		log.Logger().Debug("Incrementing major component")
 		next = previous.IncMajor()
+		s.Decision.SetBump("major", "Incrementing the major component")
 	case "minor":
 		log.Logger().Debug("Incrementing minor component")
 		next = previous.IncMinor()
+		s.Decision.SetBump("minor", "Incrementing the minor component")
 	default:
 		log.Logger().Debug("Incrementing patch component")
 		next = previous.IncPatch()
+		s.Decision.SetBump("patch", "Incrementing the patch component")
 	}
 	return &next, nil
 }
@@ -1,6 +1,7 @@
 package increment
 
 import (
+	"context"
 	"testing"
 
 	"github.com/Masterminds/semver/v3"
@@ -58,7 +59,7 @@ func TestBumpVersion(t *testing.T) {
 			s := Strategy{
 				ComponentToIncrement: test.componentToIncrement,
 			}
-			actual, err := s.BumpVersion(test.previous)
+			actual, err := s.BumpVersion(context.Background(), test.previous)
 			if test.expectedErrorMsg != "" {
 				require.EqualError(t, err, test.expectedErrorMsg)
 				assert.Nil(t, actual)
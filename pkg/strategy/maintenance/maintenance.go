@@ -0,0 +1,60 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/branch"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// Strategy constrains the next version calculated by the wrapped Bumper to the release line of a maintenance branch:
+// only patch bumps for a minor line such as `1.2`, and only minor or patch bumps for a major line such as `1.x`.
+type Strategy struct {
+	Line   branch.Line
+	Bumper strategy.VersionBumper
+	// Decision records the bump level when the version is constrained - if set
+	Decision *strategy.Decision
+}
+
+func (s Strategy) BumpVersion(ctx context.Context, previous semver.Version) (*semver.Version, error) {
+	if !s.Line.Contains(previous) {
+		first := s.Line.First()
+		if previous.LessThan(&first) {
+			log.Logger().Debugf("Previous version %s is before the release line %s - using the first version of the line", previous.String(), s.Line)
+			s.Decision.SetBump("line", fmt.Sprintf("First version of the release line %s", s.Line))
+			return &first, nil
+		}
+		return nil, fmt.Errorf("previous version %s is after the release line %s", previous.String(), s.Line)
+	}
+
+	next, err := s.Bumper.BumpVersion(ctx, previous)
+	if err != nil {
+		return nil, err
+	}
+	if s.Line.Contains(*next) {
+		return next, nil
+	}
+
+	var constrained semver.Version
+	if s.Line.AnyMinor {
+		constrained = previous.IncMinor()
+		s.Decision.SetBump("minor", s.constrainedReason())
+	} else {
+		constrained = previous.IncPatch()
+		s.Decision.SetBump("patch", s.constrainedReason())
+	}
+	log.Logger().Warnf("Next version %s is outside of the release line %s of the maintenance branch - using %s instead", next.String(), s.Line, constrained.String())
+	return &constrained, nil
+}
+
+// constrainedReason returns the reason of the wrapped bumper, extended with the constraint of the release line
+func (s Strategy) constrainedReason() string {
+	constraint := fmt.Sprintf("constrained to the release line %s", s.Line)
+	if previous := s.Decision.PreviousReason(); previous != "" {
+		return previous + " - " + constraint
+	}
+	return "Next version " + constraint
+}
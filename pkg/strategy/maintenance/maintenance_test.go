@@ -0,0 +1,88 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/branch"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/increment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBumpVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		line             branch.Line
+		increment        string
+		previous         semver.Version
+		expected         *semver.Version
+		expectedErrorMsg string
+	}{
+		{
+			name:      "patch in a minor line",
+			line:      branch.Line{Major: 1, Minor: 2},
+			increment: "patch",
+			previous:  *semver.MustParse("1.2.3"),
+			expected:  semver.MustParse("1.2.4"),
+		},
+		{
+			name:      "minor constrained in a minor line",
+			line:      branch.Line{Major: 1, Minor: 2},
+			increment: "minor",
+			previous:  *semver.MustParse("1.2.3"),
+			expected:  semver.MustParse("1.2.4"),
+		},
+		{
+			name:      "minor in a major line",
+			line:      branch.Line{Major: 1, AnyMinor: true},
+			increment: "minor",
+			previous:  *semver.MustParse("1.2.3"),
+			expected:  semver.MustParse("1.3.0"),
+		},
+		{
+			name:      "major constrained in a major line",
+			line:      branch.Line{Major: 1, AnyMinor: true},
+			increment: "major",
+			previous:  *semver.MustParse("1.2.3"),
+			expected:  semver.MustParse("1.3.0"),
+		},
+		{
+			name:      "first version of the line",
+			line:      branch.Line{Major: 1, Minor: 3},
+			increment: "patch",
+			previous:  *semver.MustParse("0.0.0"),
+			expected:  semver.MustParse("1.3.0"),
+		},
+		{
+			name:             "previous version after the line",
+			line:             branch.Line{Major: 1, Minor: 2},
+			increment:        "patch",
+			previous:         *semver.MustParse("2.0.0"),
+			expectedErrorMsg: "previous version 2.0.0 is after the release line 1.2",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := Strategy{
+				Line:   test.line,
+				Bumper: increment.Strategy{ComponentToIncrement: test.increment},
+			}
+			actual, err := s.BumpVersion(context.Background(), test.previous)
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected.String(), actual.String())
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package manual
 
 import (
+	"context"
+
 	"github.com/Masterminds/semver/v3"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 )
@@ -9,12 +11,12 @@ type Strategy struct {
 	Version string
 }
 
-func (s Strategy) ReadVersion() (*semver.Version, error) {
+func (s Strategy) ReadVersion(_ context.Context) (*semver.Version, error) {
 	log.Logger().Debugf("Using manual version %s", s.Version)
 	return semver.NewVersion(s.Version)
 }
 
-func (s Strategy) BumpVersion(_ semver.Version) (*semver.Version, error) {
+func (s Strategy) BumpVersion(_ context.Context, _ semver.Version) (*semver.Version, error) {
 	log.Logger().Debugf("Using manual version %s", s.Version)
 	return semver.NewVersion(s.Version)
 }
@@ -0,0 +1,193 @@
+package prerelease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/gitauth"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+var (
+	// channelRegexp matches the valid channel names, such as `alpha`, `beta` or `rc`
+	channelRegexp = regexp.MustCompile(`^[0-9A-Za-z-]*[A-Za-z-][0-9A-Za-z-]*$`)
+)
+
+// Strategy bumps the version to a pre-release of the next release, such as `1.3.0-rc.1`, `1.3.0-rc.2`, and so on.
+// The next release - the target - is the previous version without its pre-release if it is a pre-release,
+// so that all the pre-releases - and the final release - of a version use the same target.
+// Otherwise, the target is calculated by the wrapped Bumper.
+// The counter of the channel is the next one after the highest `<target>-<channel>.N` tag.
+type Strategy struct {
+	Dir       string
+	TagPrefix string
+	// Channel is the name of the pre-release, such as `alpha`, `beta` or `rc`.
+	// If empty, the target itself is returned: the last pre-release is promoted to the final release.
+	Channel string
+	// Bumper calculates the target from a previous version which is not a pre-release
+	Bumper strategy.VersionBumper
+	// RemoteTags reads the counters from the tags of the remote instead of the local tags -
+	// the same tags as the previous version, so that concurrent shallow clones don't release the same pre-release
+	RemoteTags bool
+	// GitAuth is the remote the tags are listed from, and its authentication
+	GitAuth gitauth.Config
+	// Decision records the bump level - if set
+	Decision *strategy.Decision
+}
+
+func (s Strategy) BumpVersion(ctx context.Context, previous semver.Version) (*semver.Version, error) {
+	if s.Channel != "" && !channelRegexp.MatchString(s.Channel) {
+		return nil, fmt.Errorf("invalid pre-release channel %q: must only contain alphanumerics and hyphens, and not be numeric", s.Channel)
+	}
+
+	target, err := s.target(ctx, previous)
+	if err != nil {
+		return nil, err
+	}
+	if s.Channel == "" {
+		log.Logger().Debugf("Promoting to the final release %s", target.String())
+		s.Decision.SetBump("promote", s.reason(previous, fmt.Sprintf("Promoting to the final release %s", target.String())))
+		return target, nil
+	}
+
+	counter, err := s.lastCounter(ctx, *target)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := target.SetPrerelease(fmt.Sprintf("%s.%d", s.Channel, counter+1))
+	if err != nil {
+		return nil, err
+	}
+	log.Logger().Debugf("Next %s pre-release of %s is %s", s.Channel, target.String(), version.String())
+	s.Decision.SetBump("prerelease", s.reason(previous, fmt.Sprintf("Next %s pre-release of %s", s.Channel, target.String())))
+	return &version, nil
+}
+
+// reason returns the reason of the bump, including the reason of the wrapped bumper if it was used
+func (s Strategy) reason(previous semver.Version, reason string) string {
+	if previous.Prerelease() == "" && s.Decision.PreviousReason() != "" {
+		return s.Decision.PreviousReason() + " - " + reason
+	}
+	return reason
+}
+
+// target returns the version of the next final release
+func (s Strategy) target(ctx context.Context, previous semver.Version) (*semver.Version, error) {
+	if previous.Prerelease() != "" {
+		target := semver.New(previous.Major(), previous.Minor(), previous.Patch(), "", "")
+		log.Logger().Debugf("Previous version %s is a pre-release of %s", previous.String(), target.String())
+		return target, nil
+	}
+
+	if s.Bumper == nil {
+		return nil, fmt.Errorf("no strategy to calculate the next release after version %s", previous.String())
+	}
+	next, err := s.Bumper.BumpVersion(ctx, previous)
+	if err != nil {
+		return nil, err
+	}
+	return semver.New(next.Major(), next.Minor(), next.Patch(), "", ""), nil
+}
+
+// lastCounter returns the highest counter N of the `<target>-<channel>.N` tags - or 0 if there are none
+func (s Strategy) lastCounter(ctx context.Context, target semver.Version) (int, error) {
+	tags, err := s.tags(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var counter int
+	for _, tag := range tags {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if !strings.HasPrefix(tag, s.TagPrefix) {
+			continue
+		}
+		v, err := semver.NewVersion(strings.TrimPrefix(tag, s.TagPrefix))
+		if err != nil || v.Major() != target.Major() || v.Minor() != target.Minor() || v.Patch() != target.Patch() {
+			continue
+		}
+		n, found := strings.CutPrefix(v.Prerelease(), s.Channel+".")
+		if !found {
+			continue
+		}
+		if c, err := strconv.Atoi(n); err == nil && c > counter {
+			log.Logger().Debugf("Found %s pre-release tag %q", s.Channel, tag)
+			counter = c
+		}
+	}
+	return counter, nil
+}
+
+// tags returns the names of the tags of the git repository - or of its remote
+func (s Strategy) tags(ctx context.Context) ([]string, error) {
+	var (
+		dir = s.Dir
+		err error
+	)
+	if dir == "" {
+		dir, err = os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current working directory: %w", err)
+		}
+	}
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository at %q: %w", dir, err)
+	}
+
+	var tags []string
+	if s.RemoteTags {
+		remoteName := s.GitAuth.RemoteName()
+		remote, err := s.GitAuth.OpenRemote(repo)
+		if err != nil {
+			return nil, err
+		}
+		auth, err := s.GitAuth.Auth(ctx, repo, remote)
+		if err != nil {
+			return nil, err
+		}
+		log.Logger().Debugf("Listing the tags of %s", remoteName)
+		listCtx, cancel := s.GitAuth.Context(ctx)
+		defer cancel()
+		refs, err := remote.ListContext(listCtx, &git.ListOptions{Auth: auth})
+		if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the tags of %s: %w", remoteName, err)
+		}
+		for _, ref := range refs {
+			if ref.Name().IsTag() {
+				tags = append(tags, ref.Name().Short())
+			}
+		}
+		return tags, nil
+	}
+
+	tagIterator, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags from git repository at %q: %w", dir, err)
+	}
+	err = tagIterator.ForEach(func(ref *plumbing.Reference) error {
+		tags = append(tags, ref.Name().Short())
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterator over tags from git repository at %q: %w", dir, err)
+	}
+	return tags, nil
+}
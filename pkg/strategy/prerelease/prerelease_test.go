@@ -0,0 +1,151 @@
+package prerelease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/gitauth"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/increment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBumpVersion(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	hash, err := w.Commit("chore: init", &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+	require.NoError(t, err)
+	for _, tagName := range []string{"v1.2.0", "v1.3.0-beta.5", "v1.3.0-rc.1", "v1.3.0-rc.2", "v1.3.0-rc.x", "v1.4.0-rc.7", "api/v1.3.0-rc.9"} {
+		_, err = repo.CreateTag(tagName, hash, nil)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name             string
+		channel          string
+		previous         semver.Version
+		expected         *semver.Version
+		expectedErrorMsg string
+	}{
+		{
+			name:     "next pre-release of the same channel",
+			channel:  "rc",
+			previous: *semver.MustParse("1.3.0-rc.2"),
+			expected: semver.MustParse("1.3.0-rc.3"),
+		},
+		{
+			name:     "next pre-release of another channel",
+			channel:  "beta",
+			previous: *semver.MustParse("1.3.0-rc.2"),
+			expected: semver.MustParse("1.3.0-beta.6"),
+		},
+		{
+			name:     "first pre-release of a new release",
+			channel:  "alpha",
+			previous: *semver.MustParse("1.2.0"),
+			expected: semver.MustParse("1.3.0-alpha.1"),
+		},
+		{
+			name:     "first pre-release with existing tags",
+			channel:  "rc",
+			previous: *semver.MustParse("1.2.0"),
+			expected: semver.MustParse("1.3.0-rc.3"),
+		},
+		{
+			name:     "promote to the final release",
+			previous: *semver.MustParse("1.3.0-rc.2"),
+			expected: semver.MustParse("1.3.0"),
+		},
+		{
+			name:     "promote a final release",
+			previous: *semver.MustParse("1.2.0"),
+			expected: semver.MustParse("1.3.0"),
+		},
+		{
+			name:             "numeric channel",
+			channel:          "1",
+			previous:         *semver.MustParse("1.2.0"),
+			expectedErrorMsg: `invalid pre-release channel "1": must only contain alphanumerics and hyphens, and not be numeric`,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := Strategy{
+				Dir:       dir,
+				TagPrefix: "v",
+				Channel:   test.channel,
+				Bumper:    increment.Strategy{ComponentToIncrement: "minor"},
+			}
+			actual, err := s.BumpVersion(context.Background(), test.previous)
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected.String(), actual.String())
+			}
+		})
+	}
+
+	t.Run("canceled context", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		s := Strategy{Dir: dir, TagPrefix: "v", Channel: "rc"}
+		_, err := s.lastCounter(ctx, *semver.MustParse("1.3.0"))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestBumpVersionFromRemoteTags(t *testing.T) {
+	t.Parallel()
+
+	remoteDir := t.TempDir()
+	remoteRepo, err := git.PlainInit(remoteDir, false)
+	require.NoError(t, err)
+	w, err := remoteRepo.Worktree()
+	require.NoError(t, err)
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	hash, err := w.Commit("chore: init", &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+	require.NoError(t, err)
+	_, err = remoteRepo.CreateTag("v1.3.0-rc.1", hash, nil)
+	require.NoError(t, err)
+
+	// the local clone doesn't have the tags, and the remote has a pre-release tag created by another build
+	localDir := t.TempDir()
+	_, err = git.PlainClone(localDir, false, &git.CloneOptions{URL: remoteDir, Tags: git.NoTags})
+	require.NoError(t, err)
+	_, err = remoteRepo.CreateTag("v1.3.0-rc.2", hash, &git.CreateTagOptions{Tagger: signature, Message: "Release version v1.3.0-rc.2"})
+	require.NoError(t, err)
+
+	s := Strategy{
+		Dir:        localDir,
+		TagPrefix:  "v",
+		Channel:    "rc",
+		RemoteTags: true,
+		GitAuth:    gitauth.Config{Remote: "file://" + remoteDir},
+	}
+	actual, err := s.BumpVersion(context.Background(), *semver.MustParse("1.3.0-rc.1"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.0-rc.3", actual.String())
+
+	s.RemoteTags = false
+	actual, err = s.BumpVersion(context.Background(), *semver.MustParse("1.3.0-rc.1"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.0-rc.1", actual.String())
+}
@@ -0,0 +1,107 @@
+package semantic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNoRelease = errors.New("no release needed: none of the commits since the previous version require a release")
+)
+
+// Bump is the component of the version to increment - if any.
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpMajor:
+		return "major"
+	case BumpMinor:
+		return "minor"
+	case BumpPatch:
+		return "patch"
+	default:
+		return "none"
+	}
+}
+
+func ParseBump(s string) (Bump, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "major":
+		return BumpMajor, nil
+	case "minor":
+		return BumpMinor, nil
+	case "patch":
+		return BumpPatch, nil
+	case "none":
+		return BumpNone, nil
+	default:
+		return BumpNone, fmt.Errorf("invalid bump %q: must be one of major, minor, patch or none", s)
+	}
+}
+
+// AnyType is the commit type of the rule used for the commits whose type has no rule,
+// and when there are no conventional commits at all.
+const AnyType = "*"
+
+// Rules maps a conventional commit type to the component of the version it bumps.
+// Breaking changes always bump the major component.
+type Rules map[string]Bump
+
+// DefaultRules bumps the minor component for new features, and the patch component for everything else.
+var DefaultRules = Rules{
+	"feat":  BumpMinor,
+	AnyType: BumpPatch,
+}
+
+// ParseRules parses a comma-separated list of `type=bump` rules, such as `perf=minor,docs=none,*=patch`,
+// on top of the default rules.
+func ParseRules(s string) (Rules, error) {
+	rules := Rules{}
+	for commitType, bump := range DefaultRules {
+		rules[commitType] = bump
+	}
+
+	for _, rule := range strings.Split(s, ",") {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid bump rule %q: must be formatted as type=bump", rule)
+		}
+		bump, err := ParseBump(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bump rule %q: %w", rule, err)
+		}
+		rules[strings.TrimSpace(parts[0])] = bump
+	}
+
+	return rules, nil
+}
+
+// Bump returns the component to bump for the given commit type.
+func (r Rules) Bump(commitType string) Bump {
+	if bump, found := r[commitType]; found {
+		return bump
+	}
+	return r[AnyType]
+}
+
+func (r Rules) String() string {
+	rules := make([]string, 0, len(r))
+	for commitType, bump := range r {
+		rules = append(rules, fmt.Sprintf("%s=%s", commitType, bump))
+	}
+	sort.Strings(rules)
+	return strings.Join(rules, ",")
+}
@@ -0,0 +1,71 @@
+package semantic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		rules            string
+		expected         Rules
+		expectedErrorMsg string
+	}{
+		{
+			name:     "default rules",
+			rules:    "",
+			expected: DefaultRules,
+		},
+		{
+			name:  "custom rules",
+			rules: "perf=minor, docs=none,chore=none,security=PATCH",
+			expected: Rules{
+				"feat":     BumpMinor,
+				"perf":     BumpMinor,
+				"docs":     BumpNone,
+				"chore":    BumpNone,
+				"security": BumpPatch,
+				AnyType:    BumpPatch,
+			},
+		},
+		{
+			name:  "override defaults",
+			rules: "feat=patch,*=none",
+			expected: Rules{
+				"feat":  BumpPatch,
+				AnyType: BumpNone,
+			},
+		},
+		{
+			name:             "missing bump",
+			rules:            "docs",
+			expectedErrorMsg: "invalid bump rule \"docs\": must be formatted as type=bump",
+		},
+		{
+			name:             "invalid bump",
+			rules:            "docs=skip",
+			expectedErrorMsg: "invalid bump rule \"docs=skip\": invalid bump \"skip\": must be one of major, minor, patch or none",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ParseRules(test.rules)
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
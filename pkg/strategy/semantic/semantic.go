@@ -1,16 +1,22 @@
 package semantic
 
 import (
+	"container/heap"
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/zbindenren/cc"
 )
@@ -24,39 +30,35 @@ type Strategy struct {
 	StripPrerelease       bool
 	CommitHeadlinesString string
 	TagPrefix             string
+	// PreviousTag is the git tag of the previous version - if already known, such as a tag matched by a tag pattern.
+	// Otherwise, the tag is found from the previous version and the tag prefix.
+	PreviousTag string
+	// Rules maps the conventional commit types to the component of the version to bump.
+	// If nil, the DefaultRules are used.
+	Rules Rules
+	// Path restricts the commits to the ones touching files in this directory,
+	// relative to the root of the git repository. Used to version a component of a monorepo.
+	Path string
+	// FirstParent only follows the first parent of merge commits,
+	// so that the commits of merged branches are ignored - like `git log --first-parent`.
+	FirstParent bool
+	// Decision records the previous tag, the commits and the bump level - if set
+	Decision *strategy.Decision
 }
 
-func (s Strategy) BumpVersion(previous semver.Version) (*semver.Version, error) {
-	var (
-		dir                   = s.Dir
-		err                   error
-		commitHeadlinesString = s.CommitHeadlinesString
-		summary               *conventionalCommitsSummary
-	)
-	if commitHeadlinesString != "" {
-		summary = s.parseCommitHeadlines(commitHeadlinesString)
-	} else {
-		if dir == "" {
-			dir, err = os.Getwd()
-			if err != nil {
-				return nil, fmt.Errorf("failed to get current working directory: %w", err)
-			}
-		}
-
-		repo, err := git.PlainOpen(dir)
-		if err != nil {
-			return nil, fmt.Errorf("failed to open git repository at %q: %w", dir, err)
-		}
-
-		tagCommit, err := s.extractTagCommit(repo, previous.String())
-		if err != nil {
-			return nil, err
-		}
+func (s Strategy) BumpVersion(ctx context.Context, previous semver.Version) (*semver.Version, error) {
+	commits, err := s.Commits(ctx, &previous)
+	if err != nil {
+		return nil, err
+	}
 
-		summary, err = s.parseCommitsSince(repo, tagCommit)
-		if err != nil {
-			return nil, err
-		}
+	summary := summarize(commits)
+	s.Decision.SetCommits(summary.commitsCount, summary.types)
+	s.Decision.SetCommitDecisions(s.classify(commits, summary))
+	if s.CommitHeadlinesString == "" && cleanPath(s.Path) != "" && summary.commitsCount == 0 {
+		log.Logger().Debugf("Found no commits touching %s - no release needed", s.Path)
+		s.Decision.SetBump(BumpNone.String(), fmt.Sprintf("Found no commits touching %s", cleanPath(s.Path)))
+		return nil, ErrNoRelease
 	}
 
 	if s.StripPrerelease {
@@ -66,34 +68,113 @@ func (s Strategy) BumpVersion(previous semver.Version) (*semver.Version, error)
 		}
 	}
 
+	bump, reason := s.bump(summary)
+	s.Decision.SetBump(bump.String(), reason)
 	var version semver.Version
-	switch {
-	case summary.breakingChanges:
-		log.Logger().Debug("Found breaking changes - incrementing major component")
+	switch bump {
+	case BumpMajor:
+		log.Logger().Debugf("%s - incrementing major component", reason)
 		version = previous.IncMajor()
-	case summary.types["feat"]:
-		log.Logger().Debug("Found at least 1 new feature - incrementing minor component")
+	case BumpMinor:
+		log.Logger().Debugf("%s - incrementing minor component", reason)
 		version = previous.IncMinor()
-	default:
-		log.Logger().Debug("Incrementing patch component")
+	case BumpPatch:
+		log.Logger().Debugf("%s - incrementing patch component", reason)
 		version = previous.IncPatch()
+	default:
+		log.Logger().Debugf("%s - no release needed", reason)
+		return nil, ErrNoRelease
 	}
 
 	return &version, nil
 }
 
+// bump returns the component to bump based on the summary of the commits, and the reason why
+func (s Strategy) bump(summary *conventionalCommitsSummary) (Bump, string) {
+	rules := s.Rules
+	if rules == nil {
+		rules = DefaultRules
+	}
+
+	if summary.breakingChanges {
+		return BumpMajor, "Found breaking changes"
+	}
+	if summary.conventionalCommitsCount == 0 {
+		return rules.Bump(AnyType), "Found no conventional commits"
+	}
+
+	types := make([]string, 0, len(summary.types))
+	for commitType := range summary.types {
+		types = append(types, commitType)
+	}
+	sort.Strings(types)
+
+	bump, bumpType := BumpNone, ""
+	for _, commitType := range types {
+		if b := rules.Bump(commitType); b > bump {
+			bump, bumpType = b, commitType
+		}
+	}
+	if bump == BumpNone {
+		return bump, fmt.Sprintf("Found only commits of types %v which don't require a release", types)
+	}
+	return bump, fmt.Sprintf("Found at least 1 %q commit", bumpType)
+}
+
+// classify returns how each commit was classified, and the bump level it requires
+func (s Strategy) classify(commits []Commit, summary *conventionalCommitsSummary) []strategy.CommitDecision {
+	rules := s.Rules
+	if rules == nil {
+		rules = DefaultRules
+	}
+
+	decisions := make([]strategy.CommitDecision, 0, len(commits))
+	for _, commit := range commits {
+		decision := strategy.CommitDecision{
+			Hash:     commit.Hash,
+			Headline: strings.TrimSpace(strings.SplitN(commit.Message, "\n", 2)[0]),
+		}
+		switch {
+		case commit.Conventional == nil && summary.conventionalCommitsCount == 0:
+			decision.Bump = rules.Bump(AnyType).String()
+		case commit.Conventional == nil:
+			// ignored, because there are conventional commits
+			decision.Bump = BumpNone.String()
+		case commit.Conventional.BreakingMessage() != "":
+			decision.Type, decision.Breaking = commit.Conventional.Header.Type, true
+			decision.Bump = BumpMajor.String()
+		default:
+			decision.Type = commit.Conventional.Header.Type
+			decision.Bump = rules.Bump(decision.Type).String()
+		}
+		decisions = append(decisions, decision)
+	}
+	return decisions
+}
+
 func (s Strategy) extractTagCommit(repo *git.Repository, tagName string) (*object.Commit, error) {
-	var tagCommit *object.Commit
+	var (
+		tagCommit      *object.Commit
+		previousTagRef *plumbing.Reference
+		err            error
+	)
 
-	previousTagRef, err := repo.Tag(tagName)
-	if err == git.ErrTagNotFound {
-		// let's try to prepend the prefix...
-		tagName = s.TagPrefix + tagName
+	if s.PreviousTag != "" {
+		tagName = s.PreviousTag
 		previousTagRef, err = repo.Tag(tagName)
-		if err == git.ErrTagNotFound {
-			return nil, ErrPreviousVersionTagNotFound
+	} else {
+		// the prefixed tag comes first, so that the component of a monorepo never uses the tag of another one
+		previousTagRef, err = repo.Tag(s.TagPrefix + tagName)
+		if err == git.ErrTagNotFound && s.TagPrefix != "" {
+			// let's try without the prefix...
+			previousTagRef, err = repo.Tag(tagName)
+		} else {
+			tagName = s.TagPrefix + tagName
 		}
 	}
+	if err == git.ErrTagNotFound {
+		return nil, ErrPreviousVersionTagNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tag %q: %w", tagName, err)
 	}
@@ -122,88 +203,385 @@ func (s Strategy) extractTagCommit(repo *git.Repository, tagName string) (*objec
 	}
 
 	log.Logger().Debugf("Previous version tag commit is %s", tagCommit.Hash)
+	s.Decision.SetPreviousTag(tagName, tagCommit.Hash.String())
 	return tagCommit, nil
 }
 
-type conventionalCommitsSummary struct {
-	conventionalCommitsCount int
-	types                    map[string]bool
-	breakingChanges          bool
+// Commit is a commit since the previous version
+type Commit struct {
+	// Hash is empty for the commit headlines passed as a string
+	Hash    string
+	Author  string
+	Date    time.Time
+	Message string
+	// Conventional is the parsed message, or nil if the commit doesn't follow the conventional commits specification
+	Conventional *cc.Commit
 }
 
-func (s Strategy) parseCommitsSince(repo *git.Repository, firstCommit *object.Commit) (*conventionalCommitsSummary, error) {
-	summary := conventionalCommitsSummary{
-		types: map[string]bool{},
+// Commits returns the commits since the given previous version - or since the beginning if it is nil -
+// from the most recent one. If the commit headlines are set, they are used instead of the git repository.
+func (s Strategy) Commits(ctx context.Context, previous *semver.Version) ([]Commit, error) {
+	if s.CommitHeadlinesString != "" {
+		return s.parseCommitHeadlines(s.CommitHeadlinesString), nil
 	}
 
-	log.Logger().Debugf("Iterating over all commits since %s", firstCommit.Committer.When)
-	commitIterator, err := repo.Log(&git.LogOptions{
-		Since: &firstCommit.Committer.When,
-		Order: git.LogOrderCommitterTime,
-	})
+	repo, err := s.openRepository()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list commits since %s (commit %q): %w", firstCommit.Committer.When, firstCommit.Hash.String(), err)
+		return nil, err
 	}
-	defer commitIterator.Close()
 
-	for {
-		commit, err := commitIterator.Next()
-		if err == io.EOF {
-			break
+	var tagCommit *object.Commit
+	if previous != nil {
+		tagCommit, err = s.extractTagCommit(repo, previous.String())
+		if err != nil {
+			return nil, err
 		}
+	}
+
+	return s.parseCommitsSince(ctx, repo, tagCommit)
+}
+
+// CommitsSince returns the commits reachable from HEAD but not from the given revision - such as a branch, a tag or a hash -
+// like `git log <revision>..HEAD`, from the most recent one. If the commit headlines are set, they are used instead of the git repository.
+func (s Strategy) CommitsSince(ctx context.Context, revision string) ([]Commit, error) {
+	if s.CommitHeadlinesString != "" {
+		return s.parseCommitHeadlines(s.CommitHeadlinesString), nil
+	}
+
+	repo, err := s.openRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve revision %q: %w", revision, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the commit with hash %q (from revision %q): %w", hash.String(), revision, err)
+	}
+
+	return s.parseCommitsSince(ctx, repo, commit)
+}
+
+// openRepository opens the git repository of the directory - or of the current working directory
+func (s Strategy) openRepository() (*git.Repository, error) {
+	dir := s.Dir
+	if dir == "" {
+		var err error
+		dir, err = os.Getwd()
 		if err != nil {
-			log.Logger().WithError(err).Debug("Skipping unretrievable commit")
+			return nil, fmt.Errorf("failed to get current working directory: %w", err)
+		}
+	}
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository at %q: %w", dir, err)
+	}
+	return repo, nil
+}
+
+type conventionalCommitsSummary struct {
+	commitsCount             int
+	conventionalCommitsCount int
+	types                    map[string]int
+	breakingChanges          bool
+}
+
+func summarize(commits []Commit) *conventionalCommitsSummary {
+	summary := conventionalCommitsSummary{
+		commitsCount: len(commits),
+		types:        map[string]int{},
+	}
+	for _, commit := range commits {
+		if commit.Conventional == nil {
 			continue
 		}
+		summary.conventionalCommitsCount++
+		summary.types[commit.Conventional.Header.Type]++
+		if commit.Conventional.BreakingMessage() != "" {
+			summary.breakingChanges = true
+		}
+	}
+
+	log.Logger().Debugf("Summary of conventional commits: %#v", summary)
+	return &summary
+}
+
+func (s Strategy) parseCommitsSince(ctx context.Context, repo *git.Repository, firstCommit *object.Commit) ([]Commit, error) {
+	gitCommits, err := s.commitsSince(ctx, repo, firstCommit)
+	if err != nil {
+		return nil, err
+	}
 
+	var commits []Commit
+	for _, commit := range gitCommits {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		log.Logger().Debugf("Checking commit %s with message %s", commit.Hash, commit.Message)
-		if commit.Hash == firstCommit.Hash {
-			log.Logger().Debugf("Found first commit %s and stopping iteration", commit.Hash)
-			break
+		if path := cleanPath(s.Path); path != "" {
+			touched, err := touchesPath(commit, path)
+			if err != nil {
+				return nil, err
+			}
+			if !touched {
+				log.Logger().Debugf("Skipping commit %s which doesn't touch %s", commit.Hash, path)
+				continue
+			}
 		}
 
 		log.Logger().Debugf("Parsing commit %s", commit.Hash)
 		c, err := cc.Parse(commit.Message)
 		if err != nil {
-			log.Logger().WithError(err).Debugf("Skipping non-conventional commit %s", commit.Hash)
+			log.Logger().WithError(err).Debugf("Found non-conventional commit %s", commit.Hash)
+			c = nil
+		}
+
+		commits = append(commits, Commit{
+			Hash:         commit.Hash.String(),
+			Author:       commit.Author.Name,
+			Date:         commit.Committer.When,
+			Message:      commit.Message,
+			Conventional: c,
+		})
+	}
+
+	return commits, nil
+}
+
+// commitsSince returns the commits reachable from HEAD but not from the given commit,
+// like `git log <commit>..HEAD` - or `git log --first-parent <commit>..HEAD` - from the most recent one.
+// If the given commit is nil, all the commits reachable from HEAD are returned.
+//
+// Like git, the commits are walked from the most recent one, and the walk stops once the remaining commits
+// are all reachable from the given commit: only the commits newer than the previous version are read,
+// instead of the whole history of the previous version.
+func (s Strategy) commitsSince(ctx context.Context, repo *git.Repository, firstCommit *object.Commit) ([]*object.Commit, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the HEAD reference: %w", err)
+	}
+
+	w := newWalk(repo)
+	w.push(head.Hash(), reachableFromHead)
+	if firstCommit != nil {
+		w.push(firstCommit.Hash, reachableFromPrevious)
+	}
+	commits, err := w.run(ctx, s.FirstParent)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Logger().Debugf("Found %d commits since the previous version", len(commits))
+	return commits, nil
+}
+
+const (
+	// reachableFromHead flags the commits reachable from HEAD
+	reachableFromHead = 1 << iota
+	// reachableFromPrevious flags the commits reachable from the commit of the previous version
+	reachableFromPrevious
+
+	// walkSlop is the number of commits walked once the remaining ones are all reachable from the previous version
+	walkSlop = 5
+)
+
+// walk walks the commits of a repository from the most recent one, flagging them with the commits they are reachable from
+type walk struct {
+	repo    *git.Repository
+	flags   map[plumbing.Hash]int
+	commits map[plumbing.Hash]*object.Commit
+	queued  map[plumbing.Hash]bool
+	queue   commitQueue
+}
+
+func newWalk(repo *git.Repository) *walk {
+	return &walk{
+		repo:    repo,
+		flags:   map[plumbing.Hash]int{},
+		commits: map[plumbing.Hash]*object.Commit{},
+		queued:  map[plumbing.Hash]bool{},
+	}
+}
+
+// push adds the flag to the commit, and queues it if the flag is new - so that its parents get the flag too.
+// Unretrievable commits - in a shallow clone for example - are ignored.
+func (w *walk) push(hash plumbing.Hash, flag int) {
+	if w.flags[hash]&flag != 0 {
+		return
+	}
+	w.flags[hash] |= flag
+	if w.queued[hash] {
+		return
+	}
+
+	commit, found := w.commits[hash]
+	if !found {
+		var err error
+		commit, err = w.repo.CommitObject(hash)
+		if err != nil {
+			log.Logger().WithError(err).Debugf("Skipping unretrievable commit %s", hash)
+			return
+		}
+		w.commits[hash] = commit
+	}
+	w.queued[hash] = true
+	heap.Push(&w.queue, commit)
+}
+
+// pop returns the most recent queued commit
+func (w *walk) pop() *object.Commit {
+	commit := heap.Pop(&w.queue).(*object.Commit)
+	w.queued[commit.Hash] = false
+	return commit
+}
+
+// run walks the queued commits, and returns the commits only reachable from HEAD, from the most recent one.
+// With firstParent, only the first parent of the merge commits reachable from HEAD is followed.
+func (w *walk) run(ctx context.Context, firstParent bool) ([]*object.Commit, error) {
+	// a few more commits are walked once the remaining ones are all reachable from the previous version,
+	// in case of commit dates out of order
+	slop := walkSlop
+	for w.queue.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !w.interesting() {
+			if slop == 0 {
+				break
+			}
+			slop--
+		}
+
+		commit := w.pop()
+		if w.flags[commit.Hash]&reachableFromPrevious != 0 {
+			for _, parent := range commit.ParentHashes {
+				w.push(parent, reachableFromPrevious)
+			}
 			continue
 		}
 
-		summary.conventionalCommitsCount++
-		summary.types[c.Header.Type] = true
-		if c.BreakingMessage() != "" {
-			summary.breakingChanges = true
+		parents := commit.ParentHashes
+		if firstParent && len(parents) > 1 {
+			parents = parents[:1]
+		}
+		for _, parent := range parents {
+			w.push(parent, reachableFromHead)
 		}
 	}
+	log.Logger().Debugf("Walked %d commits to find the commits since the previous version", len(w.commits))
 
-	log.Logger().Debugf("Summary of conventional commits since %s: %#v", firstCommit.Committer.When, summary)
-	return &summary, nil
+	var commits []*object.Commit
+	for hash, commit := range w.commits {
+		if w.flags[hash] == reachableFromHead {
+			commits = append(commits, commit)
+		}
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		if commits[i].Committer.When.Equal(commits[j].Committer.When) {
+			return commits[i].Hash.String() < commits[j].Hash.String()
+		}
+		return commits[i].Committer.When.After(commits[j].Committer.When)
+	})
+	return commits, nil
 }
 
-func (s Strategy) parseCommitHeadlines(commitHeadlinesString string) *conventionalCommitsSummary {
-	summary := conventionalCommitsSummary{
-		types: map[string]bool{},
+// interesting returns true if a queued commit is only reachable from HEAD
+func (w *walk) interesting() bool {
+	for _, commit := range w.queue {
+		if w.flags[commit.Hash] == reachableFromHead {
+			return true
+		}
+	}
+	return false
+}
+
+// commitQueue is a heap of commits, with the most recent commit first
+type commitQueue []*object.Commit
+
+func (q commitQueue) Len() int { return len(q) }
+
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].Committer.When.After(q[j].Committer.When)
+}
+
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x any) { *q = append(*q, x.(*object.Commit)) }
+
+func (q *commitQueue) Pop() any {
+	old := *q
+	commit := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return commit
+}
+
+// touchesPath returns true if the commit changed at least 1 file in the given directory,
+// compared to its first parent.
+func touchesPath(commit *object.Commit, path string) (bool, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return false, fmt.Errorf("failed to get the tree of commit %q: %w", commit.Hash.String(), err)
 	}
 
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return false, fmt.Errorf("failed to get the parent of commit %q: %w", commit.Hash.String(), err)
+		}
+		parentTree, err = parent.Tree()
+		if err != nil {
+			return false, fmt.Errorf("failed to get the tree of commit %q: %w", parent.Hash.String(), err)
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return false, fmt.Errorf("failed to list the changes of commit %q: %w", commit.Hash.String(), err)
+	}
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name == path || strings.HasPrefix(name, path+"/") {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// cleanPath returns a slash-separated path relative to the root of the repository,
+// or an empty string for the root itself.
+func cleanPath(p string) string {
+	p = filepath.ToSlash(filepath.Clean(p))
+	if p == "." {
+		return ""
+	}
+	return strings.TrimPrefix(p, "./")
+}
+
+func (s Strategy) parseCommitHeadlines(commitHeadlinesString string) []Commit {
 	log.Logger().Debugf("Iterating over all commits headline passed as a string")
 
 	commitHeadlines := regexp.MustCompile("\r?\n").Split(commitHeadlinesString, -1)
 
+	commits := make([]Commit, 0, len(commitHeadlines))
 	for index, commitHeadline := range commitHeadlines {
 		log.Logger().Debugf("Parsing commit headline number %d with message %s", index, commitHeadline)
 		c, err := cc.Parse(commitHeadline)
 		if err != nil {
-			log.Logger().WithError(err).Debugf("Skipping non-conventional commit headline number %d", index)
-			continue
+			log.Logger().WithError(err).Debugf("Found non-conventional commit headline number %d", index)
+			c = nil
 		}
 
-		summary.conventionalCommitsCount++
-		summary.types[c.Header.Type] = true
-		if c.BreakingMessage() != "" {
-			summary.breakingChanges = true
-		}
+		commits = append(commits, Commit{
+			Message:      commitHeadline,
+			Conventional: c,
+		})
 	}
 
-	log.Logger().Debugf("Summary of conventional commits: %#v", summary)
-	return &summary
+	return commits
 }
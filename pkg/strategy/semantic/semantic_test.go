@@ -1,11 +1,18 @@
 package semantic
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy"
 	archiver "github.com/jm33-m0/arc/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -87,6 +94,45 @@ feat!: a breaking feature`,
 			previous: *semver.MustParse("1.1.0"),
 			expected: semver.MustParse("1.1.1"),
 		},
+		{
+			name: "minor from custom rule",
+			strategy: Strategy{
+				CommitHeadlinesString: `fix: a fix
+perf: a performance improvement`,
+				Rules: Rules{"perf": BumpMinor, AnyType: BumpPatch},
+			},
+			previous: *semver.MustParse("1.1.0"),
+			expected: semver.MustParse("1.2.0"),
+		},
+		{
+			name: "patch from custom type",
+			strategy: Strategy{
+				CommitHeadlinesString: `docs: some docs
+security: a security fix`,
+				Rules: Rules{"docs": BumpNone, "security": BumpPatch, AnyType: BumpNone},
+			},
+			previous: *semver.MustParse("1.1.0"),
+			expected: semver.MustParse("1.1.1"),
+		},
+		{
+			name: "no release from custom rules",
+			strategy: Strategy{
+				CommitHeadlinesString: `docs: some docs
+chore: a chore`,
+				Rules: Rules{"docs": BumpNone, "chore": BumpNone, AnyType: BumpPatch},
+			},
+			previous:         *semver.MustParse("1.1.0"),
+			expectedErrorMsg: ErrNoRelease.Error(),
+		},
+		{
+			name: "breaking change with no release rule",
+			strategy: Strategy{
+				CommitHeadlinesString: "docs!: drop the old docs",
+				Rules:                 Rules{"docs": BumpNone, AnyType: BumpPatch},
+			},
+			previous: *semver.MustParse("1.1.0"),
+			expected: semver.MustParse("2.0.0"),
+		},
 		{
 			name: "feat commit with prefix",
 			strategy: Strategy{
@@ -119,7 +165,7 @@ feat!: a breaking feature`,
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
-			actual, err := test.strategy.BumpVersion(test.previous)
+			actual, err := test.strategy.BumpVersion(context.Background(), test.previous)
 			if test.expectedErrorMsg != "" {
 				require.EqualError(t, err, test.expectedErrorMsg)
 				assert.Nil(t, actual)
@@ -130,3 +176,291 @@ feat!: a breaking feature`,
 		})
 	}
 }
+
+func TestBumpVersionWithPath(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	base := commitFiles(t, repo, dir, "chore: init", "api/main.go", "web/index.html")
+	for _, tagName := range []string{"api/v1.0.0", "web/v1.0.0", "1.0.0"} {
+		_, err = repo.CreateTag(tagName, base, nil)
+		require.NoError(t, err)
+	}
+	commitFiles(t, repo, dir, "feat: a new api endpoint", "api/endpoint.go")
+	commitFiles(t, repo, dir, "fix: a web fix", "web/index.html")
+	commitFiles(t, repo, dir, "feat!: a breaking change at the root", "README.md")
+
+	tests := []struct {
+		name             string
+		strategy         Strategy
+		expected         *semver.Version
+		expectedErrorMsg string
+	}{
+		{
+			name: "api component",
+			strategy: Strategy{
+				Dir:       dir,
+				TagPrefix: "api/v",
+				Path:      "api",
+			},
+			expected: semver.MustParse("1.1.0"),
+		},
+		{
+			name: "web component",
+			strategy: Strategy{
+				Dir:       dir,
+				TagPrefix: "web/v",
+				Path:      "./web/",
+			},
+			expected: semver.MustParse("1.0.1"),
+		},
+		{
+			name: "unchanged component",
+			strategy: Strategy{
+				Dir:       dir,
+				TagPrefix: "docs/v",
+				Path:      "docs",
+			},
+			expectedErrorMsg: ErrNoRelease.Error(),
+		},
+		{
+			name: "whole repository",
+			strategy: Strategy{
+				Dir:  dir,
+				Path: ".",
+			},
+			expected: semver.MustParse("2.0.0"),
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := test.strategy.BumpVersion(context.Background(), *semver.MustParse("1.0.0"))
+			if test.expectedErrorMsg != "" {
+				require.EqualError(t, err, test.expectedErrorMsg)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBumpVersionWithMerges(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	t0 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := commitAt(t, repo, "chore: init", t0)
+	// a commit of a feature branch, with a committer date after the release which includes it
+	released := commitAt(t, repo, "feat!: an already released breaking change", t0.Add(10*time.Minute), base)
+	release := commitAt(t, repo, "Merge branch 'breaking'", t0.Add(5*time.Minute), base, released)
+	_, err = repo.CreateTag("v2.0.0", release, nil)
+	require.NoError(t, err)
+	feature := commitAt(t, repo, "feat: a new feature on the feature branch", t0.Add(11*time.Minute), released)
+	// a rebased commit, with a committer date before the previous release
+	fix := commitAt(t, repo, "fix: a rebased fix", t0.Add(-time.Hour), release)
+	commitAt(t, repo, "Merge branch 'feature'", t0.Add(12*time.Minute), fix, feature)
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+		expected *semver.Version
+	}{
+		{
+			name: "all commits since the previous release",
+			strategy: Strategy{
+				Dir:       dir,
+				TagPrefix: "v",
+			},
+			expected: semver.MustParse("2.1.0"),
+		},
+		{
+			name: "first parent",
+			strategy: Strategy{
+				Dir:         dir,
+				TagPrefix:   "v",
+				FirstParent: true,
+			},
+			expected: semver.MustParse("2.0.1"),
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := test.strategy.BumpVersion(context.Background(), *semver.MustParse("2.0.0"))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestCommitsSinceOnlyWalksNewCommits(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	t0 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	var previous plumbing.Hash
+	for i := 0; i < 100; i++ {
+		var parents []plumbing.Hash
+		if i > 0 {
+			parents = append(parents, previous)
+		}
+		previous = commitAt(t, repo, fmt.Sprintf("fix: fix %d", i), t0.Add(time.Duration(i)*time.Minute), parents...)
+	}
+	tagged, err := repo.CommitObject(previous)
+	require.NoError(t, err)
+	feat := commitAt(t, repo, "feat: a new feature", t0.Add(time.Hour*2), previous)
+	fix := commitAt(t, repo, "fix: a new fix", t0.Add(time.Hour*3), feat)
+
+	w := newWalk(repo)
+	w.push(fix, reachableFromHead)
+	w.push(tagged.Hash, reachableFromPrevious)
+	commits, err := w.run(context.Background(), false)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, fix, commits[0].Hash)
+	assert.Equal(t, feat, commits[1].Hash)
+	// the 2 new commits, the tagged commit, and a few more commits because of the slop
+	assert.LessOrEqual(t, len(w.commits), 3+walkSlop+1)
+}
+
+func TestCommits(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	base := commitFiles(t, repo, dir, "chore: init", "README.md")
+	_, err = repo.CreateTag("v1.0.0", base, nil)
+	require.NoError(t, err)
+	feat := commitFiles(t, repo, dir, "feat(api): a new endpoint\n\nCloses #12", "api.go")
+	other := commitFiles(t, repo, dir, "a non-conventional commit", "README.md")
+
+	strategy := Strategy{Dir: dir, TagPrefix: "v"}
+	commits, err := strategy.Commits(context.Background(), semver.MustParse("1.0.0"))
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, other.String(), commits[0].Hash)
+	assert.Nil(t, commits[0].Conventional)
+	assert.Equal(t, feat.String(), commits[1].Hash)
+	assert.Equal(t, "test", commits[1].Author)
+	require.NotNil(t, commits[1].Conventional)
+	assert.Equal(t, "api", commits[1].Conventional.Header.Scope)
+	assert.Equal(t, "a new endpoint", commits[1].Conventional.Header.Description)
+
+	commits, err = strategy.Commits(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Len(t, commits, 3)
+
+	commits, err = strategy.CommitsSince(context.Background(), feat.String())
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, other.String(), commits[0].Hash)
+
+	// the tag of the previous version - if known - is used instead of the version
+	commits, err = Strategy{Dir: dir, PreviousTag: "v1.0.0"}.Commits(context.Background(), semver.MustParse("1.0.0-unknown"))
+	require.NoError(t, err)
+	assert.Len(t, commits, 2)
+
+	_, err = strategy.CommitsSince(context.Background(), "unknown")
+	require.EqualError(t, err, `failed to resolve revision "unknown": reference not found`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = strategy.Commits(ctx, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBumpVersionDecision(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	base := commitFiles(t, repo, dir, "chore: init", "README.md")
+	_, err = repo.CreateTag("v1.0.0", base, nil)
+	require.NoError(t, err)
+	fix := commitFiles(t, repo, dir, "fix: a fix", "main.go")
+	feat := commitFiles(t, repo, dir, "feat: a feature\n\nwith a body", "main.go")
+	anotherFix := commitFiles(t, repo, dir, "fix: another fix", "main.go")
+	nonConventional := commitFiles(t, repo, dir, "a non-conventional commit", "main.go")
+
+	decision := &strategy.Decision{}
+	s := Strategy{Dir: dir, TagPrefix: "v", Decision: decision}
+	actual, err := s.BumpVersion(context.Background(), *semver.MustParse("1.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", actual.String())
+	assert.Equal(t, &strategy.Decision{
+		PreviousTag:    "v1.0.0",
+		PreviousCommit: base.String(),
+		Bump:           "minor",
+		Reason:         `Found at least 1 "feat" commit`,
+		CommitsCount:   4,
+		CommitTypes:    map[string]int{"feat": 1, "fix": 2},
+		Commits: []strategy.CommitDecision{
+			{Hash: nonConventional.String(), Headline: "a non-conventional commit", Bump: "none"},
+			{Hash: anotherFix.String(), Headline: "fix: another fix", Type: "fix", Bump: "patch"},
+			{Hash: feat.String(), Headline: "feat: a feature", Type: "feat", Bump: "minor"},
+			{Hash: fix.String(), Headline: "fix: a fix", Type: "fix", Bump: "patch"},
+		},
+	}, decision)
+}
+
+// commitFiles writes the given files in the worktree, and commits them with the given message.
+// Each commit is 1 minute after the previous one.
+func commitFiles(t *testing.T, repo *git.Repository, dir, message string, files ...string) plumbing.Hash {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	for _, file := range files {
+		filePath := filepath.Join(dir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0700))
+		require.NoError(t, os.WriteFile(filePath, []byte(message), 0600))
+		_, err = w.Add(file)
+		require.NoError(t, err)
+	}
+
+	var when time.Time
+	if head, err := repo.Head(); err == nil {
+		headCommit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+		when = headCommit.Committer.When.Add(time.Minute)
+	} else {
+		when = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: when}
+	hash, err := w.Commit(message, &git.CommitOptions{Author: signature, Committer: signature})
+	require.NoError(t, err)
+	return hash
+}
+
+func commitAt(t *testing.T, repo *git.Repository, message string, when time.Time, parents ...plumbing.Hash) plumbing.Hash {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: when}
+	hash, err := w.Commit(message, &git.CommitOptions{
+		Author:            signature,
+		Committer:         signature,
+		Parents:           parents,
+		AllowEmptyCommits: true,
+	})
+	require.NoError(t, err)
+	return hash
+}
@@ -1,13 +1,88 @@
 package strategy
 
 import (
+	"context"
+
 	"github.com/Masterminds/semver/v3"
 )
 
+// VersionReader reads the previous version. The context cancels the git operations - such as fetching the tags.
 type VersionReader interface {
-	ReadVersion() (*semver.Version, error)
+	ReadVersion(ctx context.Context) (*semver.Version, error)
 }
 
+// VersionBumper calculates the next version from the previous version. The context cancels the git operations.
 type VersionBumper interface {
-	BumpVersion(previous semver.Version) (*semver.Version, error)
+	BumpVersion(ctx context.Context, previous semver.Version) (*semver.Version, error)
+}
+
+// Decision records how the next version was calculated, for the machine-readable output.
+// The strategies with a Decision fill it while reading or bumping the version.
+// All its methods can be safely called on a nil Decision.
+type Decision struct {
+	// PreviousTag is the git tag of the previous version
+	PreviousTag string
+	// PreviousCommit is the hash of the commit of the previous version tag
+	PreviousCommit string
+	// Bump is the bump level, such as major, minor, patch or none
+	Bump string
+	// Reason explains why this bump level was used
+	Reason string
+	// CommitsCount is the number of commits since the previous version
+	CommitsCount int
+	// CommitTypes is the number of conventional commits since the previous version, by type
+	CommitTypes map[string]int
+	// Commits are the commits since the previous version, and how they were classified
+	Commits []CommitDecision
+}
+
+// CommitDecision records how a commit since the previous version was classified
+type CommitDecision struct {
+	Hash     string
+	Headline string
+	// Type is the conventional commit type - empty if the commit doesn't follow the conventional commits specification
+	Type     string
+	Breaking bool
+	// Bump is the bump level required by the commit
+	Bump string
+}
+
+// SetPreviousTag records the git tag - and its commit - of the previous version
+func (d *Decision) SetPreviousTag(tag, commit string) {
+	if d == nil {
+		return
+	}
+	d.PreviousTag, d.PreviousCommit = tag, commit
+}
+
+// SetBump records the bump level, and the reason why
+func (d *Decision) SetBump(bump, reason string) {
+	if d == nil {
+		return
+	}
+	d.Bump, d.Reason = bump, reason
+}
+
+// SetCommits records the number of commits since the previous version, and the number of conventional commits by type
+func (d *Decision) SetCommits(count int, types map[string]int) {
+	if d == nil {
+		return
+	}
+	d.CommitsCount, d.CommitTypes = count, types
+}
+
+// SetCommitDecisions records how the commits since the previous version were classified
+func (d *Decision) SetCommitDecisions(commits []CommitDecision) {
+	if d == nil {
+		return
+	}
+	d.Commits = commits
+}
+
+// PreviousReason returns the reason recorded so far - if any - so that a wrapping strategy can extend it
+func (d *Decision) PreviousReason() string {
+	if d == nil {
+		return ""
+	}
+	return d.Reason
 }
@@ -0,0 +1,48 @@
+package tag
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
+)
+
+// DefaultMessageTemplate is the default Go template of the message of an annotated tag
+const DefaultMessageTemplate = "Release version {{ .Tag }}"
+
+// MessageData is the data used to render the message of an annotated tag
+type MessageData struct {
+	// Tag is the name of the tag, such as `v1.2.3`
+	Tag string
+	// Version is the formatted version, such as `1.2.3`
+	Version         string
+	PreviousVersion string
+	// Commits are the commits since the previous version
+	Commits []semantic.Commit
+	// Changelog is the changelog of the commits since the previous version
+	Changelog changelog.Changelog
+	// ReleaseNotes is the changelog rendered with the changelog template
+	ReleaseNotes string
+}
+
+// RenderMessage renders the message of an annotated tag, using the default template if the given one is empty
+func RenderMessage(tmpl string, data MessageData) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultMessageTemplate
+	}
+
+	messageTemplate, err := template.New("message").Funcs(sprig.TxtFuncMap()).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the tag message template: %w", err)
+	}
+
+	message := new(strings.Builder)
+	err = messageTemplate.Execute(message, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render the tag message: %w", err)
+	}
+	return message.String(), nil
+}
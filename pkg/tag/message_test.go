@@ -0,0 +1,78 @@
+package tag
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/strategy/semantic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zbindenren/cc"
+)
+
+func TestRenderMessage(t *testing.T) {
+	t.Parallel()
+
+	feat, err := cc.Parse("feat(api): add an endpoint")
+	require.NoError(t, err)
+	commits := []semantic.Commit{
+		{Hash: "abc", Message: "feat(api): add an endpoint", Conventional: feat},
+		{Hash: "def", Message: "Merge branch 'feature'"},
+	}
+	data := MessageData{
+		Tag:             "v1.2.0",
+		Version:         "1.2.0",
+		PreviousVersion: "v1.1.0",
+		Commits:         commits,
+		Changelog:       changelog.New("1.2.0", "v1.1.0", "", commits),
+		ReleaseNotes:    "## Changes in version 1.2.0\n",
+	}
+
+	tests := []struct {
+		name             string
+		template         string
+		expected         string
+		expectedErrorMsg string
+	}{
+		{
+			name:     "default template",
+			expected: "Release version v1.2.0",
+		},
+		{
+			name:     "release notes",
+			template: "{{ .Tag }}\n\n{{ .ReleaseNotes }}",
+			expected: "v1.2.0\n\n## Changes in version 1.2.0\n",
+		},
+		{
+			name:     "commits",
+			template: "{{ .PreviousVersion }} -> {{ .Version }}{{ range .Commits }}\n- {{ .Hash }} {{ .Message }}{{ end }}",
+			expected: "v1.1.0 -> 1.2.0\n- abc feat(api): add an endpoint\n- def Merge branch 'feature'",
+		},
+		{
+			name:     "changelog",
+			template: "{{ range .Changelog.Groups }}{{ .Title }}: {{ len .Entries }}{{ end }}",
+			expected: "Features: 1",
+		},
+		{
+			name:             "invalid template",
+			template:         "{{ .Tag ",
+			expectedErrorMsg: "failed to parse the tag message template",
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := RenderMessage(test.template, data)
+			if test.expectedErrorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
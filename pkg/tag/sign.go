@@ -0,0 +1,174 @@
+package tag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// SignFormatGPG signs the tag with an OpenPGP key
+	SignFormatGPG = "gpg"
+	// SignFormatSSH signs the tag with an SSH key, like git with `gpg.format=ssh`
+	SignFormatSSH = "ssh"
+)
+
+var (
+	// ErrUnsignedTag is returned when verifying a tag which is not signed
+	ErrUnsignedTag = errors.New("the tag is not signed")
+	// ErrInvalidSignature is returned when the signature of a tag is invalid, or not made by a trusted key
+	ErrInvalidSignature = errors.New("the tag signature is invalid")
+)
+
+// readGPGKey returns the decrypted OpenPGP private key from an armored or binary key
+func readGPGKey(key []byte, passphrase string) (*openpgp.Entity, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	if err != nil {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(key))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the OpenPGP key: %w", err)
+	}
+
+	for _, entity := range entities {
+		if entity.PrivateKey == nil {
+			continue
+		}
+		if entity.PrivateKey.Encrypted {
+			if passphrase == "" {
+				return nil, errors.New("the OpenPGP key is encrypted, but no passphrase was provided")
+			}
+			if err = entity.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+				return nil, fmt.Errorf("failed to decrypt the OpenPGP key: %w", err)
+			}
+		}
+		return entity, nil
+	}
+	return nil, errors.New("no OpenPGP private key found")
+}
+
+// readSSHKey returns the signer of an SSH private key
+func readSSHKey(key []byte, passphrase string) (ssh.Signer, error) {
+	var (
+		signer ssh.Signer
+		err    error
+	)
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the SSH key: %w", err)
+	}
+	return signer, nil
+}
+
+// createSSHSignedTag creates an annotated tag signed with an SSH key.
+// go-git only signs tags with OpenPGP keys, so the tag object is built and stored directly.
+func createSSHSignedTag(repo *git.Repository, name string, hash plumbing.Hash, opts *git.CreateTagOptions, signer ssh.Signer) (*plumbing.Reference, error) {
+	refName := plumbing.NewTagReferenceName(name)
+	if _, err := repo.Reference(refName, false); err == nil {
+		return nil, git.ErrTagExists
+	}
+
+	// sets the default tagger and canonicalizes the message
+	if err := opts.Validate(repo, hash); err != nil {
+		return nil, err
+	}
+
+	tag := &object.Tag{
+		Name:       name,
+		Tagger:     *opts.Tagger,
+		Message:    opts.Message,
+		TargetType: plumbing.CommitObject,
+		Target:     hash,
+	}
+	unsigned, err := encodeWithoutSignature(tag)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := sshSign(signer, unsigned)
+	if err != nil {
+		return nil, err
+	}
+	tag.PGPSignature = signature
+
+	obj := repo.Storer.NewEncodedObject()
+	if err = tag.Encode(obj); err != nil {
+		return nil, err
+	}
+	tagHash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := plumbing.NewHashReference(refName, tagHash)
+	if err = repo.Storer.SetReference(ref); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// Verify checks that the given tag is signed by one of the trusted keys:
+// either an armored OpenPGP public keyring, or an SSH allowed signers file - for SSH signatures.
+// With an SSH allowed signers file, the key must be allowed to sign for the email of the tagger, in the git namespace.
+func Verify(dir, tagName string, trustedKeys []byte) error {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open git repository at %q: %w", dir, err)
+	}
+
+	ref, err := repo.Tag(tagName)
+	if err != nil {
+		return fmt.Errorf("failed to find tag %q: %w", tagName, err)
+	}
+	tagObject, err := repo.TagObject(ref.Hash())
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		// a lightweight tag
+		return fmt.Errorf("%w: %s is a lightweight tag", ErrUnsignedTag, tagName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tag %q: %w", tagName, err)
+	}
+	if tagObject.PGPSignature == "" {
+		return fmt.Errorf("%w: %s", ErrUnsignedTag, tagName)
+	}
+
+	if strings.HasPrefix(tagObject.PGPSignature, sshSigArmorStart) {
+		unsigned, err := encodeWithoutSignature(tagObject)
+		if err != nil {
+			return fmt.Errorf("failed to encode tag %q: %w", tagName, err)
+		}
+		if _, err = sshVerify(tagObject.PGPSignature, unsigned, parseAllowedSigners(trustedKeys), tagObject.Tagger.Email); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidSignature, tagName, err)
+		}
+		return nil
+	}
+
+	if _, err = tagObject.Verify(string(trustedKeys)); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidSignature, tagName, err)
+	}
+	return nil
+}
+
+// encodeWithoutSignature returns the content of the tag object which is signed
+func encodeWithoutSignature(tag *object.Tag) ([]byte, error) {
+	obj := &plumbing.MemoryObject{}
+	if err := tag.EncodeWithoutSignature(obj); err != nil {
+		return nil, err
+	}
+	reader, err := obj.Reader()
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
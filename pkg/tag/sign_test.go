@@ -0,0 +1,222 @@
+package tag
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSignedTag(t *testing.T) {
+	t.Parallel()
+
+	gpgKey, gpgPublicKey := generateGPGKey(t)
+	_, otherGPGPublicKey := generateGPGKey(t)
+	sshKey, sshAllowedSigners := generateSSHKey(t)
+	_, otherSSHAllowedSigners := generateSSHKey(t)
+
+	tests := []struct {
+		name        string
+		signFormat  string
+		signingKey  []byte
+		trustedKeys []byte
+		expectedErr error
+	}{
+		{
+			name:        "gpg signature",
+			signFormat:  SignFormatGPG,
+			signingKey:  gpgKey,
+			trustedKeys: gpgPublicKey,
+		},
+		{
+			name:        "ssh signature",
+			signFormat:  SignFormatSSH,
+			signingKey:  sshKey,
+			trustedKeys: sshAllowedSigners,
+		},
+		{
+			name:        "gpg signature with an untrusted key",
+			signFormat:  SignFormatGPG,
+			signingKey:  gpgKey,
+			trustedKeys: otherGPGPublicKey,
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "ssh signature with an untrusted key",
+			signFormat:  SignFormatSSH,
+			signingKey:  sshKey,
+			trustedKeys: otherSSHAllowedSigners,
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "ssh signature for another principal",
+			signFormat:  SignFormatSSH,
+			signingKey:  sshKey,
+			trustedKeys: bytes.Replace(sshAllowedSigners, []byte(GitUserEmail), []byte("someone@example.com"), 1),
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "ssh signature for another namespace",
+			signFormat:  SignFormatSSH,
+			signingKey:  sshKey,
+			trustedKeys: bytes.Replace(sshAllowedSigners, []byte(`namespaces="git"`), []byte(`namespaces="file"`), 1),
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "unsigned tag",
+			trustedKeys: gpgPublicKey,
+			expectedErr: ErrUnsignedTag,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			r, err := git.PlainInit(dir, false)
+			require.NoError(t, err)
+			w, err := r.Worktree()
+			require.NoError(t, err)
+			_, err = w.Commit("chore: init", &git.CommitOptions{
+				Author:            &object.Signature{Name: GitUserName, Email: GitUserEmail},
+				Committer:         &object.Signature{Name: GitUserName, Email: GitUserEmail},
+				AllowEmptyCommits: true,
+			})
+			require.NoError(t, err)
+
+			tagOptions := Tag{
+				Dir:              dir,
+				FormattedVersion: "v1.2.3",
+				GitName:          GitUserName,
+				GitEmail:         GitUserEmail,
+				SignFormat:       test.signFormat,
+				SigningKey:       test.signingKey,
+			}
+			err = tagOptions.TagRemote(context.Background())
+			require.NoError(t, err)
+
+			err = Verify(dir, "v1.2.3", test.trustedKeys)
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			tag, err := r.Tag("v1.2.3")
+			require.NoError(t, err)
+			tagObject, err := r.TagObject(tag.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, "Release version v1.2.3\n", tagObject.Message)
+		})
+	}
+}
+
+func TestSSHSignature(t *testing.T) {
+	t.Parallel()
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+
+	signature, err := sshSign(signer, []byte("message"))
+	require.NoError(t, err)
+	assert.Contains(t, signature, sshSigArmorStart+"\n")
+
+	signers := []allowedSigner{{principals: "*@example.com", key: signer.PublicKey()}}
+	key, err := sshVerify(signature, []byte("message"), signers, "bot@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, signer.PublicKey().Marshal(), key.Marshal())
+
+	_, err = sshVerify(signature, []byte("another message"), signers, "bot@example.com")
+	assert.ErrorContains(t, err, "invalid SSH signature")
+
+	_, err = sshVerify(signature, []byte("message"), signers, "bot@example.org")
+	assert.ErrorContains(t, err, `is not allowed to sign for "bot@example.org" in the git namespace`)
+}
+
+func TestParseAllowedSigners(t *testing.T) {
+	t.Parallel()
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+	publicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+
+	content := strings.Join([]string{
+		"# allowed signers",
+		"alice@example.com " + publicKey,
+		`"bob@example.com,*@ops.example.com,!intern@ops.example.com" namespaces="file,git" ` + publicKey + " bob's key",
+		`carol@example.com namespaces="file" ` + publicKey,
+		"dave@example.com cert-authority " + publicKey,
+		publicKey,
+		"invalid line",
+	}, "\n")
+	signers := parseAllowedSigners([]byte(content))
+	require.Len(t, signers, 3)
+
+	tests := []struct {
+		principal string
+		expected  []bool
+	}{
+		{principal: "alice@example.com", expected: []bool{true, false, false}},
+		{principal: "bob@example.com", expected: []bool{false, true, false}},
+		{principal: "eve@ops.example.com", expected: []bool{false, true, false}},
+		{principal: "intern@ops.example.com", expected: []bool{false, false, false}},
+		{principal: "carol@example.com", expected: []bool{false, false, false}},
+		{principal: "dave@example.com", expected: []bool{false, false, false}},
+	}
+	for _, test := range tests {
+		for i, signer := range signers {
+			assert.Equal(t, test.expected[i], signer.allows(test.principal, "git"), "signer %d for %s", i, test.principal)
+		}
+	}
+	assert.True(t, signers[2].allows("carol@example.com", "file"))
+}
+
+// generateGPGKey returns an armored OpenPGP private key, and its armored public key
+func generateGPGKey(t *testing.T) ([]byte, []byte) {
+	entity, err := openpgp.NewEntity(GitUserName, "", GitUserEmail, nil)
+	require.NoError(t, err)
+
+	privateKey := new(bytes.Buffer)
+	w, err := armor.Encode(privateKey, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivate(w, nil))
+	require.NoError(t, w.Close())
+
+	publicKey := new(bytes.Buffer)
+	w, err = armor.Encode(publicKey, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	return privateKey.Bytes(), publicKey.Bytes()
+}
+
+// generateSSHKey returns an OpenSSH private key, and an allowed signers file with its public key
+func generateSSHKey(t *testing.T) ([]byte, []byte) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+
+	allowedSigners := "# allowed signers\n" + GitUserEmail + ` namespaces="git" ` + string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+	return pem.EncodeToMemory(block), []byte(allowedSigners)
+}
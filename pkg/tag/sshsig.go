@@ -0,0 +1,262 @@
+package tag
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// This file implements the SSH signatures of git - `gpg.format=ssh` - using the SSHSIG format of OpenSSH:
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig
+
+const (
+	sshSigMagic        = "SSHSIG"
+	sshSigVersion      = 1
+	sshSigNamespace    = "git"
+	sshSigHashAlgo     = "sha512"
+	sshSigArmorStart   = "-----BEGIN SSH SIGNATURE-----"
+	sshSigArmorEnd     = "-----END SSH SIGNATURE-----"
+	sshSigArmorLineLen = 70
+)
+
+// sshSigBlob is the SSHSIG signature blob
+type sshSigBlob struct {
+	Magic         [6]byte
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is the data actually signed by the SSH key
+type sshSignedData struct {
+	Magic         [6]byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// sshSign returns the armored SSH signature of the message, in the git namespace
+func sshSign(signer ssh.Signer, message []byte) (string, error) {
+	data := signedData(message)
+
+	var (
+		signature *ssh.Signature
+		err       error
+	)
+	if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		// SHA-1 RSA signatures are rejected by OpenSSH
+		signature, err = algorithmSigner.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+	} else {
+		signature, err = signer.Sign(rand.Reader, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to sign with the SSH key: %w", err)
+	}
+
+	blob := sshSigBlob{
+		Version:       sshSigVersion,
+		PublicKey:     signer.PublicKey().Marshal(),
+		Namespace:     sshSigNamespace,
+		HashAlgorithm: sshSigHashAlgo,
+		Signature:     ssh.Marshal(signature),
+	}
+	copy(blob.Magic[:], sshSigMagic)
+
+	encoded := base64.StdEncoding.EncodeToString(ssh.Marshal(blob))
+	var armored strings.Builder
+	armored.WriteString(sshSigArmorStart + "\n")
+	for len(encoded) > sshSigArmorLineLen {
+		armored.WriteString(encoded[:sshSigArmorLineLen] + "\n")
+		encoded = encoded[sshSigArmorLineLen:]
+	}
+	armored.WriteString(encoded + "\n")
+	armored.WriteString(sshSigArmorEnd + "\n")
+	return armored.String(), nil
+}
+
+// sshVerify checks that the armored SSH signature of the message has been made by one of the allowed signers,
+// for the given principal in the git namespace - like `ssh-keygen -Y verify -n git -I <principal>` - and returns the key used
+func sshVerify(armored string, message []byte, signers []allowedSigner, principal string) (ssh.PublicKey, error) {
+	encoded := strings.TrimSpace(armored)
+	if !strings.HasPrefix(encoded, sshSigArmorStart) || !strings.HasSuffix(encoded, sshSigArmorEnd) {
+		return nil, errors.New("not an armored SSH signature")
+	}
+	encoded = strings.Join(strings.Fields(encoded[len(sshSigArmorStart):len(encoded)-len(sshSigArmorEnd)]), "")
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the SSH signature: %w", err)
+	}
+
+	var blob sshSigBlob
+	if err = ssh.Unmarshal(raw, &blob); err != nil {
+		return nil, fmt.Errorf("failed to parse the SSH signature: %w", err)
+	}
+	if string(blob.Magic[:]) != sshSigMagic || blob.Version != sshSigVersion {
+		return nil, errors.New("unsupported SSH signature format")
+	}
+	if blob.Namespace != sshSigNamespace {
+		return nil, fmt.Errorf("invalid SSH signature namespace %q: must be %q", blob.Namespace, sshSigNamespace)
+	}
+	if blob.HashAlgorithm != sshSigHashAlgo {
+		return nil, fmt.Errorf("unsupported SSH signature hash algorithm %q", blob.HashAlgorithm)
+	}
+
+	publicKey, err := ssh.ParsePublicKey(blob.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the public key of the SSH signature: %w", err)
+	}
+	var known, allowed bool
+	for _, signer := range signers {
+		if !bytes.Equal(signer.key.Marshal(), publicKey.Marshal()) {
+			continue
+		}
+		known = true
+		if signer.allows(principal, sshSigNamespace) {
+			allowed = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("the SSH signature was made by the untrusted key %s", ssh.FingerprintSHA256(publicKey))
+	}
+	if !allowed {
+		return nil, fmt.Errorf("the key %s is not allowed to sign for %q in the %s namespace", ssh.FingerprintSHA256(publicKey), principal, sshSigNamespace)
+	}
+
+	signature := new(ssh.Signature)
+	if err = ssh.Unmarshal(blob.Signature, signature); err != nil {
+		return nil, fmt.Errorf("failed to parse the SSH signature: %w", err)
+	}
+	if err = publicKey.Verify(signedData(message), signature); err != nil {
+		return nil, fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	return publicKey, nil
+}
+
+// signedData returns the SSHSIG data to sign for the message
+func signedData(message []byte) []byte {
+	hash := sha512.Sum512(message)
+	data := sshSignedData{
+		Namespace:     sshSigNamespace,
+		HashAlgorithm: sshSigHashAlgo,
+		Hash:          hash[:],
+	}
+	copy(data.Magic[:], sshSigMagic)
+	return ssh.Marshal(data)
+}
+
+// allowedSigner is an entry of an allowed signers file: the principals allowed to sign with a key
+type allowedSigner struct {
+	// principals are the comma-separated patterns of the principals, such as `*@example.com,!bot@example.com`
+	principals string
+	// namespaces are the namespaces the key can sign in - all of them if empty
+	namespaces []string
+	key        ssh.PublicKey
+}
+
+// allows returns true if the signer can sign for the principal in the namespace
+func (s allowedSigner) allows(principal, namespace string) bool {
+	if len(s.namespaces) > 0 && !matchPatternList(namespace, strings.Join(s.namespaces, ",")) {
+		return false
+	}
+	return matchPatternList(principal, s.principals)
+}
+
+// parseAllowedSigners parses the entries of an allowed signers file - as used by `gpg.ssh.allowedSignersFile`,
+// see the ALLOWED SIGNERS section of ssh-keygen(1). Each line is made of the principals, the options and the public key.
+// The certificate authorities aren't supported, and their lines are ignored - as the lines which can't be parsed.
+func parseAllowedSigners(content []byte) []allowedSigner {
+	var signers []allowedSigner
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		principals, rest := cutPrincipals(line)
+		key, _, options, _, err := ssh.ParseAuthorizedKey([]byte(rest))
+		if principals == "" || err != nil {
+			continue
+		}
+
+		signer := allowedSigner{principals: principals, key: key}
+		certAuthority := false
+		for _, option := range options {
+			name, value, _ := strings.Cut(option, "=")
+			switch strings.ToLower(name) {
+			case "cert-authority":
+				certAuthority = true
+			case "namespaces":
+				signer.namespaces = strings.Split(strings.Trim(value, `"`), ",")
+			}
+		}
+		if certAuthority {
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	return signers
+}
+
+// cutPrincipals returns the principals of an allowed signers line - which may be quoted - and the rest of the line
+func cutPrincipals(line string) (string, string) {
+	if strings.HasPrefix(line, `"`) {
+		if end := strings.Index(line[1:], `"`); end >= 0 {
+			return line[1 : end+1], strings.TrimSpace(line[end+2:])
+		}
+		return "", ""
+	}
+	principals, rest, _ := strings.Cut(line, " ")
+	return principals, strings.TrimSpace(rest)
+}
+
+// matchPatternList returns true if the value matches the comma-separated patterns, like the pattern lists of OpenSSH:
+// `*` and `?` are wildcards, and a pattern prefixed with `!` excludes the values it matches.
+func matchPatternList(value, patterns string) bool {
+	matched := false
+	for _, pattern := range strings.Split(patterns, ",") {
+		negated := strings.HasPrefix(pattern, "!")
+		if !matchPattern(value, strings.TrimPrefix(pattern, "!")) {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// matchPattern returns true if the value matches the pattern, where `*` matches any characters and `?` matches 1 character
+func matchPattern(value, pattern string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(value); i >= 0; i-- {
+				if matchPattern(value[i:], pattern[1:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if value == "" {
+				return false
+			}
+		default:
+			if value == "" || value[0] != pattern[0] {
+				return false
+			}
+		}
+		value, pattern = value[1:], pattern[1:]
+	}
+	return value == ""
+}
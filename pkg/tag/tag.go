@@ -1,6 +1,7 @@
 package tag
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -8,20 +9,40 @@ import (
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/gitauth"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 )
 
+var (
+	// ErrRemoteTagConflict is returned when the remote already has a tag with the same name, pointing to another commit
+	ErrRemoteTagConflict = errors.New("the tag already exists on the remote and points to another commit")
+)
+
 type Tag struct {
 	FormattedVersion string
 	Dir              string
 	PushTag          bool
 	GitName          string
 	GitEmail         string
+	// SignFormat is the format of the tag signature: gpg or ssh. The tag is not signed if empty.
+	SignFormat string
+	// SigningKey is the private key used to sign the tag: an OpenPGP key, or an SSH key
+	SigningKey           []byte
+	SigningKeyPassphrase string
+	// Message is the message of the annotated tag. Default to `Release version <tag>`.
+	Message string
+	// Lightweight creates a lightweight tag, instead of an annotated tag
+	Lightweight bool
+	// GitAuth is the remote the tag is pushed to, and its authentication
+	GitAuth gitauth.Config
 }
 
-func (options Tag) TagRemote() error {
+// TagRemote creates the tag on the HEAD commit, and pushes it if enabled.
+// The context cancels the push, on top of the timeout of the network operations set in the GitAuth options.
+func (options Tag) TagRemote(ctx context.Context) error {
 	var err error
 	if options.Dir == "" {
 		options.Dir, err = os.Getwd()
@@ -32,6 +53,9 @@ func (options Tag) TagRemote() error {
 	if options.FormattedVersion == "" {
 		return errors.New("no version to use for tag")
 	}
+	if options.Lightweight && options.SignFormat != "" {
+		return errors.New("a lightweight tag can't be signed")
+	}
 
 	repo, err := git.PlainOpen(options.Dir)
 	if err != nil {
@@ -44,7 +68,10 @@ func (options Tag) TagRemote() error {
 	}
 
 	tagOptions := &git.CreateTagOptions{
-		Message: fmt.Sprintf("Release version %s", options.FormattedVersion),
+		Message: options.Message,
+	}
+	if tagOptions.Message == "" {
+		tagOptions.Message = fmt.Sprintf("Release version %s", options.FormattedVersion)
 	}
 
 	// override default git config tagger info
@@ -57,46 +84,127 @@ func (options Tag) TagRemote() error {
 		}
 	}
 
-	log.Logger().Debugf("git tag -a %s -m %q", options.FormattedVersion, tagOptions.Message)
-	_, err = repo.CreateTag(options.FormattedVersion, h.Hash(), tagOptions)
+	tagRef, err := options.createTag(repo, h.Hash(), tagOptions)
 	if err != nil {
 		return fmt.Errorf("failed to create tag %q with message %q: %w", options.FormattedVersion, tagOptions.Message, err)
 	}
 
 	if options.PushTag {
-		return pushTags(repo)
+		return options.pushTag(ctx, repo, tagRef, h.Hash())
 	}
 	return nil
 }
 
-func pushTags(r *git.Repository) error {
-	token := os.Getenv("GIT_TOKEN")
-
-	po := &git.PushOptions{
-		RemoteName: "origin",
-		Progress:   os.Stderr,
-		RefSpecs:   []config.RefSpec{config.RefSpec("refs/tags/*:refs/tags/*")},
+// createTag creates the tag: either a lightweight tag, or an annotated tag - signed if a sign format is set
+func (options Tag) createTag(repo *git.Repository, hash plumbing.Hash, tagOptions *git.CreateTagOptions) (*plumbing.Reference, error) {
+	if options.Lightweight {
+		log.Logger().Debugf("git tag %s", options.FormattedVersion)
+		return repo.CreateTag(options.FormattedVersion, hash, nil)
 	}
 
-	if token != "" {
-		user := os.Getenv("GIT_USER")
-		if user == "" {
-			user = "abc123" // yes, this can be anything except an empty string
+	switch options.SignFormat {
+	case "":
+		log.Logger().Debugf("git tag -a %s -m %q", options.FormattedVersion, tagOptions.Message)
+		return repo.CreateTag(options.FormattedVersion, hash, tagOptions)
+	case SignFormatGPG:
+		key, err := readGPGKey(options.SigningKey, options.SigningKeyPassphrase)
+		if err != nil {
+			return nil, err
 		}
-		po.Auth = &http.BasicAuth{
-			Username: user,
-			Password: token,
+		tagOptions.SignKey = key
+		log.Logger().Debugf("git tag -s %s -m %q", options.FormattedVersion, tagOptions.Message)
+		return repo.CreateTag(options.FormattedVersion, hash, tagOptions)
+	case SignFormatSSH:
+		signer, err := readSSHKey(options.SigningKey, options.SigningKeyPassphrase)
+		if err != nil {
+			return nil, err
 		}
+		log.Logger().Debugf("git -c gpg.format=ssh tag -s %s -m %q", options.FormattedVersion, tagOptions.Message)
+		return createSSHSignedTag(repo, options.FormattedVersion, hash, tagOptions, signer)
+	default:
+		return nil, fmt.Errorf("invalid sign format %q: must be %s or %s", options.SignFormat, SignFormatGPG, SignFormatSSH)
 	}
-	log.Logger().Debug("git push --tags")
-	err := r.Push(po)
+}
+
+// pushTag pushes only the given tag - and not the other local tags - to the remote.
+// The commit is the one the tag points to, used to check the tag of the same name on the remote - if any.
+func (options Tag) pushTag(ctx context.Context, r *git.Repository, tagRef *plumbing.Reference, commit plumbing.Hash) error {
+	remoteName := options.GitAuth.RemoteName()
+	remote, err := options.GitAuth.OpenRemote(r)
+	if err != nil {
+		return err
+	}
+	auth, err := options.GitAuth.Auth(ctx, r, remote)
+	if err != nil {
+		return err
+	}
+
+	listCtx, cancel := options.GitAuth.Context(ctx)
+	pushed, err := checkRemoteTag(listCtx, r, remote, remoteName, tagRef, commit, auth)
+	cancel()
+	if err != nil {
+		return err
+	}
+	if pushed {
+		log.Logger().Debugf("tag %s already exists on the %s remote for the same commit, no push done", tagRef.Name().Short(), remoteName)
+		return nil
+	}
+
+	refSpec := config.RefSpec(fmt.Sprintf("%s:%s", tagRef.Name(), tagRef.Name()))
+	po := &git.PushOptions{
+		RemoteName: remote.Config().Name,
+		Progress:   os.Stderr,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Auth:       auth,
+	}
+	log.Logger().Debugf("git push %s %s", remoteName, refSpec)
+	pushCtx, cancel := options.GitAuth.Context(ctx)
+	defer cancel()
+	err = remote.PushContext(pushCtx, po)
 
 	if err != nil {
 		if err == git.NoErrAlreadyUpToDate {
-			log.Logger().Debug("origin remote was up to date, no push done")
+			log.Logger().Debugf("%s remote was up to date, no push done", remoteName)
 			return nil
 		}
-		return fmt.Errorf("failed to push tags to origin: %w", err)
+		return fmt.Errorf("failed to push tag %s to %s: %w", tagRef.Name().Short(), remoteName, err)
 	}
 	return nil
 }
+
+// checkRemoteTag returns true if the remote already has the tag for the given commit,
+// and ErrRemoteTagConflict if the remote has a tag with the same name for another commit.
+func checkRemoteTag(ctx context.Context, r *git.Repository, remote *git.Remote, remoteName string, tagRef *plumbing.Reference, commit plumbing.Hash, auth transport.AuthMethod) (bool, error) {
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth, PeelingOption: git.AppendPeeled})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		// a new repository, without any reference yet
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to list the references of the %s remote: %w", remoteName, err)
+	}
+
+	var remoteHash, remotePeeledHash plumbing.Hash
+	for _, ref := range refs {
+		switch ref.Name().String() {
+		case tagRef.Name().String():
+			remoteHash = ref.Hash()
+		case tagRef.Name().String() + "^{}":
+			remotePeeledHash = ref.Hash()
+		}
+	}
+	if remoteHash.IsZero() {
+		return false, nil
+	}
+	if remoteHash == tagRef.Hash() || remoteHash == commit || remotePeeledHash == commit {
+		return true, nil
+	}
+	if remotePeeledHash.IsZero() {
+		// the remote didn't advertise the commit of its annotated tag: try to find the tag object locally
+		if tagObject, err := r.TagObject(remoteHash); err == nil && tagObject.Target == commit {
+			return true, nil
+		}
+		remotePeeledHash = remoteHash
+	}
+	return false, fmt.Errorf("%w: tag %s points to %s on the %s remote, instead of %s", ErrRemoteTagConflict, tagRef.Name().Short(), remotePeeledHash, remoteName, commit)
+}
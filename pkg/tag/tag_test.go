@@ -1,13 +1,20 @@
 package tag
 
 import (
+	"context"
+	"net"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/gitauth"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const GitUserName string = "test"
@@ -55,7 +62,7 @@ func TestTag(t *testing.T) {
 		FormattedVersion: "1.2.3",
 	}
 
-	err = tagOptions.TagRemote()
+	err = tagOptions.TagRemote(context.Background())
 	assert.NoError(t, err)
 
 	tags, err := r.TagObjects()
@@ -66,3 +73,229 @@ func TestTag(t *testing.T) {
 
 	assert.Equal(t, "1.2.3", tag.Name)
 }
+
+func TestTagPush(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		remoteTag   string
+		emptyRemote bool
+		expectedErr error
+	}{
+		{
+			name: "only push the new tag",
+		},
+		{
+			name:        "empty remote",
+			emptyRemote: true,
+		},
+		{
+			name:      "remote tag for the same commit",
+			remoteTag: "head",
+		},
+		{
+			name:        "remote tag for another commit",
+			remoteTag:   "parent",
+			expectedErr: ErrRemoteTagConflict,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			remoteDir := t.TempDir()
+			remoteRepo, err := git.PlainInit(remoteDir, true)
+			require.NoError(t, err)
+
+			dir := t.TempDir()
+			r, err := git.PlainInit(dir, false)
+			require.NoError(t, err)
+			_, err = r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}})
+			require.NoError(t, err)
+
+			w, err := r.Worktree()
+			require.NoError(t, err)
+			co := &git.CommitOptions{
+				Author:            &object.Signature{Name: GitUserName, Email: GitUserEmail},
+				Committer:         &object.Signature{Name: GitUserName, Email: GitUserEmail},
+				AllowEmptyCommits: true,
+			}
+			parent, err := w.Commit("chore: init", co)
+			require.NoError(t, err)
+			head, err := w.Commit("feat: a feature", co)
+			require.NoError(t, err)
+			if !test.emptyRemote {
+				err = r.Push(&git.PushOptions{RemoteName: "origin", RefSpecs: []config.RefSpec{"refs/heads/*:refs/heads/*"}})
+				require.NoError(t, err)
+			}
+
+			switch test.remoteTag {
+			case "head":
+				_, err = remoteRepo.CreateTag("v1.2.3", head, &git.CreateTagOptions{Tagger: co.Author, Message: "Release version v1.2.3"})
+			case "parent":
+				_, err = remoteRepo.CreateTag("v1.2.3", parent, &git.CreateTagOptions{Tagger: co.Author, Message: "Release version v1.2.3"})
+			}
+			require.NoError(t, err)
+
+			// a stray local tag, which should not be pushed
+			_, err = r.CreateTag("stray", parent, nil)
+			require.NoError(t, err)
+
+			tagOptions := Tag{
+				Dir:              dir,
+				PushTag:          true,
+				FormattedVersion: "v1.2.3",
+				GitName:          GitUserName,
+				GitEmail:         GitUserEmail,
+			}
+			err = tagOptions.TagRemote(context.Background())
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			remoteTag, err := remoteRepo.Tag("v1.2.3")
+			require.NoError(t, err)
+			tagObject, err := remoteRepo.TagObject(remoteTag.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, head, tagObject.Target)
+			_, err = remoteRepo.Tag("stray")
+			assert.ErrorIs(t, err, git.ErrTagNotFound)
+		})
+	}
+}
+
+func TestTagPushSlowRemote(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := r.Worktree()
+	require.NoError(t, err)
+	_, err = w.Commit("chore: init", &git.CommitOptions{
+		Author:            &object.Signature{Name: GitUserName, Email: GitUserEmail},
+		Committer:         &object.Signature{Name: GitUserName, Email: GitUserEmail},
+		AllowEmptyCommits: true,
+	})
+	require.NoError(t, err)
+
+	tagOptions := Tag{
+		Dir:              dir,
+		PushTag:          true,
+		FormattedVersion: "v1.2.3",
+		GitName:          GitUserName,
+		GitEmail:         GitUserEmail,
+		GitAuth:          gitauth.Config{Remote: slowRemote(t), Timeout: 100 * time.Millisecond},
+	}
+	start := time.Now()
+	err = tagOptions.TagRemote(context.Background())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// the tag is still created locally
+	_, err = r.Tag("v1.2.3")
+	assert.NoError(t, err)
+}
+
+// slowRemote starts a local server which accepts the connections but never answers, and returns the URL of its git remote
+func slowRemote(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	var (
+		mutex sync.Mutex
+		conns []net.Conn
+	)
+	t.Cleanup(func() {
+		_ = listener.Close()
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mutex.Lock()
+			conns = append(conns, conn)
+			mutex.Unlock()
+		}
+	}()
+	return "http://" + listener.Addr().String() + "/repo.git"
+}
+
+func TestTagKind(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		message         string
+		lightweight     bool
+		expectedMessage string
+	}{
+		{
+			name:            "default message",
+			expectedMessage: "Release version v1.2.3\n",
+		},
+		{
+			name:            "custom message",
+			message:         "v1.2.3\n\n## Features\n\n- a feature",
+			expectedMessage: "v1.2.3\n\n## Features\n\n- a feature\n",
+		},
+		{
+			name:        "lightweight tag",
+			message:     "ignored",
+			lightweight: true,
+		},
+	}
+
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			r, err := git.PlainInit(dir, false)
+			require.NoError(t, err)
+			w, err := r.Worktree()
+			require.NoError(t, err)
+			head, err := w.Commit("chore: init", &git.CommitOptions{
+				Author:            &object.Signature{Name: GitUserName, Email: GitUserEmail},
+				Committer:         &object.Signature{Name: GitUserName, Email: GitUserEmail},
+				AllowEmptyCommits: true,
+			})
+			require.NoError(t, err)
+
+			tagOptions := Tag{
+				Dir:              dir,
+				FormattedVersion: "v1.2.3",
+				GitName:          GitUserName,
+				GitEmail:         GitUserEmail,
+				Message:          test.message,
+				Lightweight:      test.lightweight,
+			}
+			err = tagOptions.TagRemote(context.Background())
+			require.NoError(t, err)
+
+			tag, err := r.Tag("v1.2.3")
+			require.NoError(t, err)
+			if test.lightweight {
+				assert.Equal(t, head, tag.Hash())
+				return
+			}
+			tagObject, err := r.TagObject(tag.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedMessage, tagObject.Message)
+		})
+	}
+}
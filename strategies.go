@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-release-version/v2/pkg/release"
+)
+
+// parseReader returns the strategy reading the previous version of the `-previous-version` flag, formatted as `name` or `name:arg`.
+// A value which isn't the name of a strategy - such as `1.2.3` - is the version of the manual strategy.
+func parseReader(s string) release.Reader {
+	name, arg, _ := strings.Cut(s, ":")
+	switch name {
+	case "", release.AutoStrategy:
+		return release.Auto{}
+	case "from-tag":
+		return release.FromTag{Pattern: arg}
+	case "from-file":
+		return release.FromFile{Path: arg}
+	case release.ManualStrategy:
+		return release.Manual{Version: arg}
+	default:
+		return release.Manual{Version: s}
+	}
+}
+
+// parseBumper returns the strategy calculating the next version of the `-next-version` flag, formatted as `name` or `name:arg`.
+// A value which isn't the name of a strategy - such as `1.2.3` - is the version of the manual strategy.
+func parseBumper(s string) release.Bumper {
+	name, arg, _ := strings.Cut(s, ":")
+	switch name {
+	case "", release.AutoStrategy:
+		return release.Auto{Semantic: parseSemantic(arg)}
+	case "semantic":
+		return parseSemantic(arg)
+	case "from-file":
+		return release.FromFile{Path: arg}
+	case "increment":
+		return release.Increment{Component: arg}
+	case "calver":
+		return release.CalVer{Format: arg}
+	case release.DescribeStrategy:
+		return release.Describe{Channel: describeChannel(arg), Semantic: parseSemantic(arg)}
+	case release.ManualStrategy:
+		return release.Manual{Version: arg}
+	default:
+		return release.Manual{Version: s}
+	}
+}
+
+// parseSemantic returns the options of the semantic strategy of the comma-separated argument, such as `strip-prerelease,first-parent`
+func parseSemantic(arg string) release.Semantic {
+	var s release.Semantic
+	for _, option := range strings.Split(arg, ",") {
+		switch strings.TrimSpace(option) {
+		case "strip-prerelease":
+			s.StripPrerelease = true
+		case "first-parent":
+			s.FirstParent = true
+		}
+	}
+	return s
+}
+
+// describeChannel returns the channel of the describe strategy argument - the option which is not a semantic strategy option
+func describeChannel(arg string) string {
+	for _, option := range strings.Split(arg, ",") {
+		if option = strings.TrimSpace(option); option != "" && option != "strip-prerelease" && option != "first-parent" {
+			return option
+		}
+	}
+	return ""
+}
+
+// semanticOptions returns the options of the semantic strategy used by the next version strategy - to list the commits of the changelog
+func semanticOptions(b release.Bumper) release.Semantic {
+	switch b := b.(type) {
+	case release.Auto:
+		return b.Semantic
+	case release.Semantic:
+		return b
+	case release.Describe:
+		return b.Semantic
+	default:
+		return release.Semantic{}
+	}
+}
+
+// isDescribe returns true if the next version strategy is the describe strategy, which calculates development versions
+func isDescribe() bool {
+	_, describe := parseBumper(options.nextVersion).(release.Describe)
+	return describe
+}